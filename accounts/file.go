@@ -0,0 +1,45 @@
+package accounts
+
+import (
+	"gopcr/config"
+	"gopcr/core"
+	"gopcr/log"
+)
+
+// NewPoolFromFile 由配置文件创建账号池：账号、并发数以及每个账号的服务器、代理和设备信息，
+// 并按配置设置全局日志级别。账号所属服务器为配置中的 server。options在配置之后应用。
+// 读取或保存设备文件失败时返回错误
+func NewPoolFromFile(f *config.File, options ...PoolOption) (*Pool, error) {
+	if f.LogLevel != "" {
+		log.SetLevel(f.Level())
+	}
+
+	accounts := make([]core.SdkAccount, 0, len(f.Accounts))
+	accountOptions := make(map[string][]core.SessionOption, len(f.Accounts))
+	servers := make(map[string]string, len(f.Accounts))
+	for _, account := range f.Accounts {
+		sdkAccount, sessionOptions, err := core.FileAccount(f, account)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, sdkAccount)
+		accountOptions[account.Uid] = sessionOptions
+		servers[account.Uid] = account.Server
+	}
+
+	fileOptions := []PoolOption{
+		WithServerOf(func(account core.SdkAccount) string {
+			return servers[account.Uid]
+		}),
+		WithAccountOptions(func(account core.SdkAccount) []core.SessionOption {
+			return accountOptions[account.Uid]
+		}),
+	}
+	if f.Concurrency > 0 {
+		fileOptions = append(fileOptions, WithConcurrency(f.Concurrency))
+	}
+	if f.ServerConcurrency > 0 {
+		fileOptions = append(fileOptions, WithServerConcurrency(f.ServerConcurrency))
+	}
+	return NewPool(accounts, append(fileOptions, options...)...), nil
+}
@@ -0,0 +1,259 @@
+// Package accounts 管理多个账号的客户端，并发地在账号上执行任务
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopcr/core"
+	"sync"
+	"time"
+)
+
+// Job 在单个账号的客户端上执行的任务
+type Job func(ctx context.Context, client *core.Client) error
+
+// Result 单个账号的任务执行结果
+type Result struct {
+	Account  core.SdkAccount
+	Server   string
+	Err      error
+	Duration time.Duration
+}
+
+// Report 一次 Run 的结果，顺序与账号列表一致
+type Report struct {
+	Results []Result
+}
+
+// Failed 返回失败的结果
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err 合并所有失败账号的错误，全部成功时返回nil
+func (r *Report) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Account.Uid, result.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// entry 账号及其懒加载的客户端
+type entry struct {
+	account core.SdkAccount
+	server  string
+
+	mu     sync.Mutex
+	client *core.Client
+}
+
+// Pool 账号池。客户端在第一次使用时创建，并在之后的任务间复用。
+// 所有客户端默认共享 config.Default()，任一客户端发现的新AppVer会被其他客户端直接使用
+type Pool struct {
+	entries []*entry
+	byUid   map[string]*entry
+
+	concurrency       int
+	serverConcurrency int
+	serverOf          func(account core.SdkAccount) string
+	sessionOptions    []core.SessionOption
+	accountOptions    func(account core.SdkAccount) []core.SessionOption
+
+	global  chan struct{}
+	mu      sync.Mutex
+	servers map[string]chan struct{}
+}
+
+// PoolOption 定义账号池选项
+type PoolOption func(*Pool)
+
+// WithConcurrency 全局同时执行任务的账号数，默认为4
+func WithConcurrency(n int) PoolOption {
+	return func(p *Pool) {
+		p.concurrency = n
+	}
+}
+
+// WithServerConcurrency 每个服务器同时执行任务的账号数，<=0 表示不限制（默认）
+func WithServerConcurrency(n int) PoolOption {
+	return func(p *Pool) {
+		p.serverConcurrency = n
+	}
+}
+
+// WithServerOf 自定义账号所属服务器的判断，默认使用渠道ID(Channel)
+func WithServerOf(fn func(account core.SdkAccount) string) PoolOption {
+	return func(p *Pool) {
+		p.serverOf = fn
+	}
+}
+
+// WithSessionOptions 所有客户端共用的 core.SessionOption
+func WithSessionOptions(options ...core.SessionOption) PoolOption {
+	return func(p *Pool) {
+		p.sessionOptions = append(p.sessionOptions, options...)
+	}
+}
+
+// WithAccountOptions 按账号追加 core.SessionOption，例如渠道服账号使用 core.WithChannelServer
+func WithAccountOptions(fn func(account core.SdkAccount) []core.SessionOption) PoolOption {
+	return func(p *Pool) {
+		p.accountOptions = fn
+	}
+}
+
+// NewPool 创建账号池，此时不会创建任何客户端
+func NewPool(accounts []core.SdkAccount, options ...PoolOption) *Pool {
+	p := &Pool{
+		byUid:       make(map[string]*entry),
+		concurrency: 4,
+		serverOf: func(account core.SdkAccount) string {
+			return account.Channel
+		},
+		servers: make(map[string]chan struct{}),
+	}
+	for _, option := range options {
+		option(p)
+	}
+	if p.concurrency <= 0 {
+		p.concurrency = 1
+	}
+	p.global = make(chan struct{}, p.concurrency)
+
+	for _, account := range accounts {
+		e := &entry{account: account, server: p.serverOf(account)}
+		p.entries = append(p.entries, e)
+		p.byUid[account.Uid] = e
+	}
+	return p
+}
+
+// Accounts 返回池中的账号
+func (p *Pool) Accounts() []core.SdkAccount {
+	accounts := make([]core.SdkAccount, 0, len(p.entries))
+	for _, e := range p.entries {
+		accounts = append(accounts, e.account)
+	}
+	return accounts
+}
+
+// Client 返回账号的客户端，不存在时创建
+func (p *Pool) Client(ctx context.Context, uid string) (*core.Client, error) {
+	e, ok := p.byUid[uid]
+	if !ok {
+		return nil, fmt.Errorf("账号不存在: %s", uid)
+	}
+	return p.client(ctx, e)
+}
+
+func (p *Pool) client(ctx context.Context, e *entry) (*core.Client, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.client != nil {
+		return e.client, nil
+	}
+	options := append([]core.SessionOption(nil), p.sessionOptions...)
+	if p.accountOptions != nil {
+		options = append(options, p.accountOptions(e.account)...)
+	}
+	client, err := core.NewClient(ctx, e.account, options...)
+	if err != nil {
+		return nil, err
+	}
+	e.client = client
+	return client, nil
+}
+
+// serverSem 返回服务器的并发限制，不限制时返回nil
+func (p *Pool) serverSem(server string) chan struct{} {
+	if p.serverConcurrency <= 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sem, ok := p.servers[server]
+	if !ok {
+		sem = make(chan struct{}, p.serverConcurrency)
+		p.servers[server] = sem
+	}
+	return sem
+}
+
+// Run 在所有账号上执行任务，等待全部完成后返回结果。
+// ctx取消后尚未开始的账号不再执行，结果中记录ctx的错误
+func (p *Pool) Run(ctx context.Context, job Job) *Report {
+	report := &Report{Results: make([]Result, len(p.entries))}
+
+	var wg sync.WaitGroup
+	for i, e := range p.entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := p.runOne(ctx, e, job)
+			report.Results[i] = Result{
+				Account:  e.account,
+				Server:   e.server,
+				Err:      err,
+				Duration: time.Since(start),
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (p *Pool) runOne(ctx context.Context, e *entry, job Job) error {
+	// 先获取服务器的执行权，避免等待服务器时占用全局名额
+	if sem := p.serverSem(e.server); sem != nil {
+		if err := acquire(ctx, sem); err != nil {
+			return err
+		}
+		defer func() { <-sem }()
+	}
+	if err := acquire(ctx, p.global); err != nil {
+		return err
+	}
+	defer func() { <-p.global }()
+	// 等待期间ctx被取消时，select可能仍然选中了信号量
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	client, err := p.client(ctx, e)
+	if err != nil {
+		return err
+	}
+	return job(ctx, client)
+}
+
+// Close 关闭所有已创建的客户端
+func (p *Pool) Close() {
+	for _, e := range p.entries {
+		e.mu.Lock()
+		if e.client != nil {
+			e.client.Close()
+			e.client = nil
+		}
+		e.mu.Unlock()
+	}
+}
+
+func acquire(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,218 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopcr/config"
+	"gopcr/core"
+	"gopcr/mockserver"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gauge 统计同时进行中的请求数
+type gauge struct {
+	mu       sync.Mutex
+	cur, max int
+}
+
+// middleware 在请求期间计数，并稍作等待使请求重叠
+func (g *gauge) middleware() core.Middleware {
+	return func(next core.Handler) core.Handler {
+		return func(ctx context.Context, ex *core.Exchange) error {
+			g.mu.Lock()
+			g.cur++
+			g.max = max(g.max, g.cur)
+			g.mu.Unlock()
+			defer func() {
+				g.mu.Lock()
+				g.cur--
+				g.mu.Unlock()
+			}()
+			time.Sleep(5 * time.Millisecond)
+			return next(ctx, ex)
+		}
+	}
+}
+
+func (g *gauge) peak() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.max
+}
+
+// testAccounts 生成n个账号，按下标轮流分配到servers个渠道
+func testAccounts(n, servers int) []core.SdkAccount {
+	accounts := make([]core.SdkAccount, n)
+	for i := range accounts {
+		accounts[i] = core.SdkAccount{
+			Uid:       fmt.Sprintf("u%d", i),
+			AccessKey: "key",
+			Platform:  "2",
+			Channel:   fmt.Sprint(i%servers + 1),
+		}
+	}
+	return accounts
+}
+
+// newTestPool 创建连接到模拟服务器的账号池，测试结束时关闭
+func newTestPool(t *testing.T, srv *mockserver.Server, accounts []core.SdkAccount, options ...PoolOption) *Pool {
+	t.Helper()
+	cfg := config.NewBili()
+	options = append([]PoolOption{
+		WithSessionOptions(core.WithBaseURL(srv.URL), core.WithConfig(cfg)),
+	}, options...)
+	p := NewPool(accounts, options...)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func homeIndex(ctx context.Context, client *core.Client) error {
+	_, err := client.HomeIndex(ctx)
+	return err
+}
+
+func TestPoolConcurrencyLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		concurrency       int
+		serverConcurrency int
+	}{
+		{"global", 3, 0},
+		{"per server", 8, 2},
+		{"both", 3, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := mockserver.New()
+			defer srv.Close()
+
+			var global gauge
+			servers := map[string]*gauge{"1": {}, "2": {}}
+			p := newTestPool(t, srv, testAccounts(12, len(servers)),
+				WithConcurrency(tc.concurrency),
+				WithServerConcurrency(tc.serverConcurrency),
+				WithSessionOptions(core.WithMiddleware(global.middleware())),
+				WithAccountOptions(func(account core.SdkAccount) []core.SessionOption {
+					return []core.SessionOption{core.WithMiddleware(servers[account.Channel].middleware())}
+				}),
+			)
+
+			if err := p.Run(context.Background(), homeIndex).Err(); err != nil {
+				t.Fatal(err)
+			}
+			limit := tc.concurrency
+			if tc.serverConcurrency > 0 {
+				limit = min(limit, tc.serverConcurrency*len(servers))
+			}
+			if peak := global.peak(); peak > limit || peak < 2 {
+				t.Fatalf("peak in-flight requests %d, want 2..%d", peak, limit)
+			}
+			for server, g := range servers {
+				if tc.serverConcurrency > 0 && g.peak() > tc.serverConcurrency {
+					t.Fatalf("server %s: peak in-flight requests %d, want <= %d", server, g.peak(), tc.serverConcurrency)
+				}
+			}
+			if errs := srv.Errors(); len(errs) > 0 {
+				t.Fatal(errs)
+			}
+		})
+	}
+}
+
+func TestPoolCreatesClientsLazily(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	accounts := testAccounts(3, 1)
+	p := newTestPool(t, srv, accounts)
+	if n := srv.Calls(mockserver.EndpointSourceIniIndex); n != 0 {
+		t.Fatalf("NewPool sent %d requests", n)
+	}
+
+	for range 2 {
+		if err := p.Run(context.Background(), homeIndex).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 客户端在任务之间复用，每个账号只登录一次
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != len(accounts) {
+		t.Fatalf("sdk_login called %d times, want %d", n, len(accounts))
+	}
+	a, err := p.Client(context.Background(), "u0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := p.Client(context.Background(), "u0"); a != b {
+		t.Fatal("Client returned a different client for the same account")
+	}
+	if _, err = p.Client(context.Background(), "unknown"); err == nil {
+		t.Fatal("expected an error for an unknown account")
+	}
+}
+
+func TestPoolReport(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	accounts := testAccounts(6, 2)
+	p := newTestPool(t, srv, accounts, WithConcurrency(6))
+
+	errOdd := errors.New("odd")
+	report := p.Run(context.Background(), func(ctx context.Context, client *core.Client) error {
+		if err := homeIndex(ctx, client); err != nil {
+			return err
+		}
+		state := client.ExportState()
+		// 让后面的账号先完成，检查结果仍按账号顺序排列
+		time.Sleep(time.Duration(len(accounts)-int(state.ViewerId%100)) * 2 * time.Millisecond)
+		if state.ViewerId%2 == 1 {
+			return errOdd
+		}
+		return nil
+	})
+	if len(report.Results) != len(accounts) {
+		t.Fatalf("got %d results", len(report.Results))
+	}
+	for i, result := range report.Results {
+		if result.Account != accounts[i] || result.Server != accounts[i].Channel {
+			t.Fatalf("result %d is for %+v, want %+v", i, result.Account, accounts[i])
+		}
+		wantErr := srv.ViewerId(accounts[i].Uid)%2 == 1
+		if (result.Err != nil) != wantErr {
+			t.Fatalf("result %d: err %v", i, result.Err)
+		}
+	}
+	if len(report.Failed()) != len(accounts)/2 || !errors.Is(report.Err(), errOdd) {
+		t.Fatalf("failed %d, err %v", len(report.Failed()), report.Err())
+	}
+}
+
+func TestPoolRunCancel(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	accounts := testAccounts(4, 1)
+	p := newTestPool(t, srv, accounts, WithConcurrency(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var ran atomic.Int32
+	report := p.Run(ctx, func(ctx context.Context, client *core.Client) error {
+		ran.Add(1)
+		// 第一个任务执行时取消，其余账号不再执行
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if n := ran.Load(); n != 1 {
+		t.Fatalf("%d jobs ran, want 1", n)
+	}
+	for i, result := range report.Results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Fatalf("result %d: got %v, want context.Canceled", i, result.Err)
+		}
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 0 {
+		t.Fatalf("sdk_login called %d times, want 0", n)
+	}
+}
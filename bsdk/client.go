@@ -0,0 +1,197 @@
+// Package bsdk 实现B站游戏SDK的账号登录，用于获取 core.SdkAccount 所需的uid和access_key
+package bsdk
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gopcr/captcha"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBaseURL B站游戏SDK接口地址
+	DefaultBaseURL = "https://line1-sdk-center-login-sh.biligame.net/"
+	// DefaultAppKey 参数签名使用的app key
+	DefaultAppKey = "fe8aac4e02f845b8ad67c427d48bfaf1"
+)
+
+// 接口路径
+const (
+	rsaPath          = "api/client/rsa"
+	loginPath        = "api/client/login"
+	renewalPath      = "api/client/session.renewal"
+	startCaptchaPath = "api/client/start_captcha"
+)
+
+// codeCaptchaRequired 登录需要验证码
+const codeCaptchaRequired = 200000
+
+// Error SDK接口返回的错误
+type Error struct {
+	Path    string
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("bsdk %s failed: code=%d, message=%s", e.Path, e.Code, e.Message)
+}
+
+// Client B站游戏SDK客户端
+type Client struct {
+	baseURL    string
+	appKey     string
+	httpClient *http.Client
+	solver     captcha.Solver
+	params     map[string]string
+}
+
+// Option 定义SDK客户端选项
+type Option func(*Client)
+
+// WithBaseURL 自定义SDK接口地址，例如指向本地的模拟服务器
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/") + "/"
+	}
+}
+
+// WithAppKey 自定义参数签名使用的app key
+func WithAppKey(appKey string) Option {
+	return func(c *Client) {
+		c.appKey = appKey
+	}
+}
+
+// WithHTTPClient 自定义HTTP客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithCaptchaSolver 登录需要验证码时使用的求解器，未设置时返回 ErrCaptchaRequired
+func WithCaptchaSolver(solver captcha.Solver) Option {
+	return func(c *Client) {
+		c.solver = solver
+	}
+}
+
+// WithParams 覆盖或追加每个请求都会携带的公共参数
+func WithParams(params map[string]string) Option {
+	return func(c *Client) {
+		for k, v := range params {
+			c.params[k] = v
+		}
+	}
+}
+
+// New 创建SDK客户端
+func New(options ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultBaseURL,
+		appKey:     DefaultAppKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		params:     defaultParams(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// defaultParams 公共参数
+func defaultParams() map[string]string {
+	return map[string]string{
+		"game_id":       "1370",
+		"app_id":        "1370",
+		"merchant_id":   "1",
+		"server_id":     "1592",
+		"channel_id":    "1",
+		"platform_type": "3",
+		"sdk_type":      "1",
+		"sdk_ver":       "5.9.0",
+		"version":       "1",
+		"c":             "1",
+		"domain":        "line1-sdk-center-login-sh.biligame.net",
+	}
+}
+
+// sign 参数签名：按key排序后拼接所有value，加上app key取md5
+func sign(params url.Values, appKey string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(params.Get(k))
+	}
+	b.WriteString(appKey)
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// post 发送签名后的表单请求并解析响应。result需包含code和message字段以外的业务字段
+func (c *Client) post(ctx context.Context, path string, extra map[string]string, result any) error {
+	params := url.Values{}
+	for k, v := range c.params {
+		params.Set(k, v)
+	}
+	for k, v := range extra {
+		params.Set(k, v)
+	}
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	params.Set("timestamp", now)
+	params.Set("client_timestamp", now)
+	params.Set("sign", sign(params, c.appKey))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Mozilla/5.0 BSGameSDK")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("bsdk %s request failed: %w", path, err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bsdk %s unexpected status code: %d", path, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("bsdk %s failed to read response: %w", path, err)
+	}
+
+	var status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err = json.Unmarshal(body, &status); err != nil {
+		return fmt.Errorf("bsdk %s invalid JSON: %w", path, err)
+	}
+	if status.Code != 0 {
+		return &Error{Path: path, Code: status.Code, Message: status.Message}
+	}
+	if err = json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("bsdk %s invalid JSON: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package bsdk
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"gopcr/captcha"
+	"gopcr/core"
+	"strconv"
+	"time"
+)
+
+// ErrCaptchaRequired 登录需要验证码，但没有设置求解器
+var ErrCaptchaRequired = errors.New("bsdk: 登录需要验证码")
+
+// Token 登录得到的账号凭证
+type Token struct {
+	Uid       string    `json:"uid"`
+	AccessKey string    `json:"access_key"`
+	Uname     string    `json:"uname"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired access_key是否已过期
+func (t *Token) Expired() bool {
+	return !t.ExpiresAt.IsZero() && !time.Now().Before(t.ExpiresAt)
+}
+
+// SdkAccount 转换为 core.SdkAccount（B服）
+func (t *Token) SdkAccount() core.SdkAccount {
+	return core.SdkAccount{
+		Uid:       t.Uid,
+		AccessKey: t.AccessKey,
+		Platform:  "2",
+		Channel:   "1",
+	}
+}
+
+// loginResult login/session.renewal 接口的响应
+type loginResult struct {
+	Uid       flexString `json:"uid"`
+	AccessKey string     `json:"access_key"`
+	Uname     string     `json:"uname"`
+	Expires   int64      `json:"expires"`
+}
+
+func (r *loginResult) token() *Token {
+	token := &Token{
+		Uid:       string(r.Uid),
+		AccessKey: r.AccessKey,
+		Uname:     r.Uname,
+	}
+	if r.Expires > 0 {
+		// 兼容毫秒与秒
+		if r.Expires > 1e12 {
+			token.ExpiresAt = time.UnixMilli(r.Expires)
+		} else {
+			token.ExpiresAt = time.Unix(r.Expires, 0)
+		}
+	}
+	return token
+}
+
+// flexString 兼容数字和字符串形式的uid
+type flexString string
+
+func (j *flexString) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	*j = flexString(s)
+	return nil
+}
+
+// encryptPassword 使用 api/client/rsa 返回的公钥加密 hash+password
+func (c *Client) encryptPassword(ctx context.Context, password string) (string, error) {
+	var result struct {
+		Hash   string `json:"hash"`
+		RsaKey string `json:"rsa_key"`
+	}
+	if err := c.post(ctx, rsaPath, nil, &result); err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode([]byte(result.RsaKey))
+	if block == nil {
+		return "", errors.New("bsdk: 无效的RSA公钥")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("bsdk: 解析RSA公钥失败: %w", err)
+	}
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return "", errors.New("bsdk: 公钥不是RSA公钥")
+	}
+	encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, pub, []byte(result.Hash+password))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// Login 使用用户名和密码登录。需要验证码时调用求解器后重新登录
+func (c *Client) Login(ctx context.Context, username, password string) (*Token, error) {
+	pwd, err := c.encryptPassword(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]string{
+		"user_id": username,
+		"pwd":     pwd,
+	}
+
+	var result loginResult
+	err = c.post(ctx, loginPath, params, &result)
+	var sdkErr *Error
+	if errors.As(err, &sdkErr) && sdkErr.Code == codeCaptchaRequired {
+		if err = c.solveCaptcha(ctx, password, params); err != nil {
+			return nil, err
+		}
+		err = c.post(ctx, loginPath, params, &result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.AccessKey == "" {
+		return nil, errors.New("bsdk: access_key为空")
+	}
+	return result.token(), nil
+}
+
+// solveCaptcha 获取并求解验证码，将结果填入登录参数
+func (c *Client) solveCaptcha(ctx context.Context, password string, params map[string]string) error {
+	if c.solver == nil {
+		return ErrCaptchaRequired
+	}
+	challenge, err := c.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	solution, err := c.solver.Solve(ctx, challenge)
+	if err != nil {
+		return fmt.Errorf("bsdk: 验证码求解失败: %w", err)
+	}
+	// 密码的hash只能使用一次，重新加密
+	if params["pwd"], err = c.encryptPassword(ctx, password); err != nil {
+		return err
+	}
+	params["gt_user_id"] = challenge.GtUserId
+	params["challenge"] = solution.Challenge
+	params["validate"] = solution.Validate
+	params["seccode"] = solution.Seccode
+	return nil
+}
+
+// Refresh 续期access_key，返回新的凭证
+func (c *Client) Refresh(ctx context.Context, token *Token) (*Token, error) {
+	var result loginResult
+	err := c.post(ctx, renewalPath, map[string]string{
+		"uid":        token.Uid,
+		"access_key": token.AccessKey,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	refreshed := result.token()
+	if refreshed.Uid == "" {
+		refreshed.Uid = token.Uid
+	}
+	if refreshed.AccessKey == "" {
+		refreshed.AccessKey = token.AccessKey
+	}
+	if refreshed.Uname == "" {
+		refreshed.Uname = token.Uname
+	}
+	return refreshed, nil
+}
+
+// Fetch 获取极验挑战，实现 captcha.Fetcher，可用于 core.WithCaptchaSolver
+func (c *Client) Fetch(ctx context.Context) (*captcha.Challenge, error) {
+	var challenge captcha.Challenge
+	if err := c.post(ctx, startCaptchaPath, nil, &challenge); err != nil {
+		return nil, err
+	}
+	if challenge.Challenge == "" {
+		return nil, errors.New("bsdk: challenge为空")
+	}
+	return &challenge, nil
+}
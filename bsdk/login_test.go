@@ -0,0 +1,122 @@
+package bsdk
+
+import (
+	"context"
+	"errors"
+	"gopcr/captcha"
+	"gopcr/config"
+	"gopcr/core"
+	"gopcr/mockserver"
+	"testing"
+	"time"
+)
+
+// newTestServer 创建注册了SDK账号的模拟服务器
+func newTestServer(t *testing.T, user mockserver.SdkUser) *mockserver.Server {
+	t.Helper()
+	srv := mockserver.New()
+	t.Cleanup(srv.Close)
+	srv.AddSdkUser(user)
+	return srv
+}
+
+// checkGameLogin 用SDK得到的凭证登录游戏
+func checkGameLogin(t *testing.T, srv *mockserver.Server, token *Token) {
+	t.Helper()
+	c, err := core.NewClient(context.Background(), token.SdkAccount(),
+		core.WithBaseURL(srv.URL), core.WithConfig(config.NewBili()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	srv := newTestServer(t, mockserver.SdkUser{Username: "user", Password: "pass", Uid: "123"})
+	token, err := New(WithBaseURL(srv.URL)).Login(context.Background(), "user", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Uid != "123" || token.Uname != "user" || token.AccessKey == "" {
+		t.Fatalf("got %+v", token)
+	}
+	if token.Expired() || token.ExpiresAt.Before(time.Now().Add(24*time.Hour)) {
+		t.Fatalf("unexpected expiry %v", token.ExpiresAt)
+	}
+	checkGameLogin(t, srv, token)
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestLoginBadPassword(t *testing.T) {
+	srv := newTestServer(t, mockserver.SdkUser{Username: "user", Password: "pass", Uid: "123"})
+	c := New(WithBaseURL(srv.URL))
+	for _, tc := range []struct{ username, password string }{
+		{"user", "wrong"},
+		{"nobody", "pass"},
+	} {
+		_, err := c.Login(context.Background(), tc.username, tc.password)
+		var sdkErr *Error
+		if !errors.As(err, &sdkErr) || sdkErr.Code != 500002 {
+			t.Fatalf("%s/%s: got %v, want bad password error", tc.username, tc.password, err)
+		}
+	}
+}
+
+func TestLoginCaptcha(t *testing.T) {
+	srv := newTestServer(t, mockserver.SdkUser{Username: "user", Password: "pass", Uid: "123", CaptchaRequired: true})
+
+	_, err := New(WithBaseURL(srv.URL)).Login(context.Background(), "user", "pass")
+	if !errors.Is(err, ErrCaptchaRequired) {
+		t.Fatalf("got %v, want ErrCaptchaRequired", err)
+	}
+
+	c := New(WithBaseURL(srv.URL), WithCaptchaSolver(captcha.StaticSolver(mockserver.SolveChallenge)))
+	token, err := c.Login(context.Background(), "user", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Uid != "123" {
+		t.Fatalf("got %+v", token)
+	}
+	if n := srv.Calls(mockserver.EndpointCaptcha); n != 1 {
+		t.Fatalf("start_captcha called %d times, want 1", n)
+	}
+
+	// 错误的验证码结果不会重试
+	wrong := captcha.StaticSolver(func(string) string { return "wrong" })
+	_, err = New(WithBaseURL(srv.URL), WithCaptchaSolver(wrong)).Login(context.Background(), "user", "pass")
+	var sdkErr *Error
+	if !errors.As(err, &sdkErr) || sdkErr.Code != codeCaptchaRequired {
+		t.Fatalf("got %v, want captcha required error", err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	srv := newTestServer(t, mockserver.SdkUser{Username: "user", Password: "pass", Uid: "123"})
+	c := New(WithBaseURL(srv.URL))
+	token, err := c.Login(context.Background(), "user", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed, err := c.Refresh(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.AccessKey == token.AccessKey || refreshed.Uid != "123" || refreshed.Uname != "user" {
+		t.Fatalf("got %+v", refreshed)
+	}
+	checkGameLogin(t, srv, refreshed)
+
+	// 续期后旧的access_key失效
+	_, err = c.Refresh(context.Background(), token)
+	var sdkErr *Error
+	if !errors.As(err, &sdkErr) || sdkErr.Code != 500003 {
+		t.Fatalf("got %v, want invalid token error", err)
+	}
+}
@@ -0,0 +1,41 @@
+// Package captcha 定义sdk_login触发风控时使用的验证码接口
+package captcha
+
+import "context"
+
+// Challenge 验证码挑战
+type Challenge struct {
+	Gt         string `json:"gt"`
+	Challenge  string `json:"challenge"`
+	GtUserId   string `json:"gt_user_id"`
+	ImageToken string `json:"image_token,omitempty"` // 图片验证码使用
+	Image      []byte `json:"image,omitempty"`       // 图片验证码内容
+}
+
+// Solution 验证码结果，对应 models.SdkLoginReq 中的同名字段
+type Solution struct {
+	CaptchaType string `json:"captcha_type"`
+	Challenge   string `json:"challenge"`
+	Validate    string `json:"validate"`
+	Seccode     string `json:"seccode"`
+	ImageToken  string `json:"image_token"`
+	CaptchaCode string `json:"captcha_code"`
+}
+
+// Solver 验证码求解器
+type Solver interface {
+	Solve(ctx context.Context, challenge *Challenge) (*Solution, error)
+}
+
+// Fetcher 获取验证码挑战
+type Fetcher interface {
+	Fetch(ctx context.Context) (*Challenge, error)
+}
+
+// FetcherFunc 函数形式的 Fetcher
+type FetcherFunc func(ctx context.Context) (*Challenge, error)
+
+// Fetch 实现 Fetcher
+func (f FetcherFunc) Fetch(ctx context.Context) (*Challenge, error) {
+	return f(ctx)
+}
@@ -0,0 +1,209 @@
+package captcha
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// geetestCaptchaType sdk_login中极验验证码的captcha_type
+const geetestCaptchaType = "1"
+
+// newGeetestSolution 由极验的validate构造结果，seccode固定为 validate|jordan
+func newGeetestSolution(challenge *Challenge, validate string) *Solution {
+	return &Solution{
+		CaptchaType: geetestCaptchaType,
+		Challenge:   challenge.Challenge,
+		Validate:    validate,
+		Seccode:     validate + "|jordan",
+		ImageToken:  challenge.ImageToken,
+	}
+}
+
+// StdinSolver 人工求解：打印挑战信息，从输入读取validate。
+// 零值可以直接使用，此时读取 os.Stdin、输出到 os.Stdout。
+// 输入由一个goroutine逐行读取，多次 Solve 共用，读到EOF或调用 Close 后退出；
+// 某次 Solve 因ctx取消而未读到输入时，之后输入的第一行视为对该次提示的回答并丢弃
+type StdinSolver struct {
+	In  io.Reader // 为nil时使用 os.Stdin
+	Out io.Writer // 为nil时使用 os.Stdout
+
+	once      sync.Once
+	closeOnce sync.Once
+	lines     chan string
+	done      chan struct{}
+	err       error // 读取goroutine退出的原因，lines关闭后可读
+	mu        sync.Mutex
+	stale     int // 需要丢弃的行数
+}
+
+// errSolverClosed 已调用 StdinSolver.Close
+var errSolverClosed = errors.New("solver is closed")
+
+// start 启动唯一的读取goroutine
+func (s *StdinSolver) start() {
+	s.once.Do(func() {
+		in := s.In
+		if in == nil {
+			in = os.Stdin
+		}
+		s.lines = make(chan string)
+		s.done = make(chan struct{})
+		go func() {
+			defer close(s.lines)
+			reader := bufio.NewReader(in)
+			for {
+				text, err := reader.ReadString('\n')
+				if err != nil && text == "" {
+					s.err = err
+					return
+				}
+				// 读取期间已关闭时丢弃该行
+				select {
+				case <-s.done:
+					s.err = errSolverClosed
+					return
+				default:
+				}
+				select {
+				case s.lines <- strings.TrimSpace(text):
+				case <-s.done:
+					s.err = errSolverClosed
+					return
+				}
+			}
+		}()
+	})
+}
+
+// Close 停止读取goroutine，之后的 Solve 返回错误。
+// 正在阻塞的读取无法中断，goroutine在该次读取返回后退出
+func (s *StdinSolver) Close() error {
+	s.once.Do(func() {
+		// 尚未开始读取，不再启动goroutine
+		s.lines = make(chan string)
+		close(s.lines)
+		s.done = make(chan struct{})
+		s.err = errSolverClosed
+	})
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+// Solve 实现 Solver
+func (s *StdinSolver) Solve(ctx context.Context, challenge *Challenge) (*Solution, error) {
+	s.start()
+
+	out := s.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	_, _ = fmt.Fprintf(out, "需要完成验证码\ngt: %s\nchallenge: %s\ngt_user_id: %s\n请输入validate: ",
+		challenge.Gt, challenge.Challenge, challenge.GtUserId)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.stale++
+			s.mu.Unlock()
+			return nil, ctx.Err()
+		case <-s.done:
+			return nil, errSolverClosed
+		case text, ok := <-s.lines:
+			if !ok {
+				return nil, fmt.Errorf("failed to read validate: %w", s.err)
+			}
+			s.mu.Lock()
+			skip := s.stale > 0
+			if skip {
+				s.stale--
+			}
+			s.mu.Unlock()
+			if skip {
+				continue
+			}
+			if text == "" {
+				return nil, errors.New("validate is empty")
+			}
+			return newGeetestSolution(challenge, text), nil
+		}
+	}
+}
+
+// HTTPSolver 将挑战以JSON POST到回调地址，由外部服务（打码平台、人工页面等）返回 Solution JSON
+type HTTPSolver struct {
+	URL    string
+	Client *http.Client // 为nil时使用 http.DefaultClient，超时由ctx控制
+}
+
+// Solve 实现 Solver
+func (s *HTTPSolver) Solve(ctx context.Context, challenge *Challenge) (*Solution, error) {
+	body, err := json.Marshal(challenge)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("captcha callback failed: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	var solution Solution
+	if err = json.NewDecoder(resp.Body).Decode(&solution); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if solution.Validate == "" && solution.CaptchaCode == "" {
+		return nil, errors.New("empty captcha solution")
+	}
+	if solution.Challenge == "" {
+		solution.Challenge = challenge.Challenge
+	}
+	if solution.CaptchaType == "" {
+		solution.CaptchaType = geetestCaptchaType
+	}
+	if solution.Seccode == "" && solution.Validate != "" {
+		solution.Seccode = solution.Validate + "|jordan"
+	}
+	return &solution, nil
+}
+
+// SolverFunc 函数形式的 Solver，可用作测试桩
+type SolverFunc func(ctx context.Context, challenge *Challenge) (*Solution, error)
+
+// Solve 实现 Solver
+func (f SolverFunc) Solve(ctx context.Context, challenge *Challenge) (*Solution, error) {
+	return f(ctx, challenge)
+}
+
+// StaticSolver 返回由挑战计算的固定validate，用于测试
+func StaticSolver(validate func(challenge string) string) Solver {
+	return SolverFunc(func(_ context.Context, challenge *Challenge) (*Solution, error) {
+		return newGeetestSolution(challenge, validate(challenge.Challenge)), nil
+	})
+}
@@ -0,0 +1,87 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStdinSolverKeepsBufferedInput(t *testing.T) {
+	s := &StdinSolver{In: strings.NewReader("first\nsecond\n"), Out: io.Discard}
+	for _, want := range []string{"first", "second"} {
+		solution, err := s.Solve(context.Background(), &Challenge{Challenge: "c"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if solution.Validate != want || solution.Seccode != want+"|jordan" {
+			t.Fatalf("got %+v, want validate %q", solution, want)
+		}
+	}
+	if _, err := s.Solve(context.Background(), &Challenge{}); err == nil {
+		t.Fatal("expected error at EOF")
+	}
+}
+
+func TestStdinSolverDiscardsAnswerToCancelledPrompt(t *testing.T) {
+	r, w := io.Pipe()
+	s := &StdinSolver{In: r, Out: io.Discard}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Solve(ctx, &Challenge{}); err == nil {
+		t.Fatal("expected ctx error")
+	}
+
+	go func() {
+		_, _ = io.WriteString(w, "late\nfresh\n")
+	}()
+	solution, err := s.Solve(context.Background(), &Challenge{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solution.Validate != "fresh" {
+		t.Fatalf("got %q, want the line typed after the cancelled prompt to be discarded", solution.Validate)
+	}
+}
+
+func TestStdinSolverClose(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	s := &StdinSolver{In: r, Out: io.Discard}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Solve(ctx, &Challenge{}); err == nil {
+		t.Fatal("expected ctx error")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Solve(context.Background(), &Challenge{}); !errors.Is(err, errSolverClosed) {
+		t.Fatalf("got %v, want errSolverClosed", err)
+	}
+
+	// 阻塞的读取返回后goroutine退出，不再读取之后的输入
+	go func() {
+		_, _ = io.WriteString(w, "unblock\n")
+	}()
+	select {
+	case _, ok := <-s.lines:
+		if ok {
+			t.Fatal("line delivered after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reader goroutine did not exit")
+	}
+}
+
+func TestStdinSolverCloseBeforeSolve(t *testing.T) {
+	s := &StdinSolver{In: strings.NewReader("never read\n"), Out: io.Discard}
+	_ = s.Close()
+	if _, err := s.Solve(context.Background(), &Challenge{}); !errors.Is(err, errSolverClosed) {
+		t.Fatalf("got %v, want errSolverClosed", err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"text/template"
+)
+
+const header = "// Code generated by gopcr-gen. DO NOT EDIT.\n\n"
+
+var funcs = template.FuncMap{
+	"tag": func(f Field) string {
+		return fmt.Sprintf("`json:%q`", f.JSON)
+	},
+}
+
+var modelsTmpl = template.Must(template.New("models").Funcs(funcs).Parse(header + `package models
+
+import "net/url"
+
+{{range .Types}}
+{{- if .Doc}}// {{.Name}} {{.Doc}}
+{{end -}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} {{tag .}}{{if .Doc}} // {{.Doc}}{{end}}
+{{- end}}
+}
+
+{{end}}
+{{- range .Endpoints}}
+// {{.Name}}{{if .Doc}} {{.Doc}}{{end}}
+const {{.PathConst}} = "{{.Path}}"
+
+type {{.Name}}Req struct {
+	BaseRequest
+{{range .Request}}
+	{{.Name}} {{.Type}} {{tag .}}{{if .Doc}} // {{.Doc}}{{end}}
+{{- end}}
+}
+
+func New{{.Name}}Req() {{.Name}}Req {
+	req := {{.Name}}Req{
+		BaseRequest: NewBaseRequest(),
+{{- range .Request}}{{if .Default}}
+		{{.Name}}: {{.Default}},
+{{- end}}{{end}}
+	}
+{{- if not .Encrypted}}
+	// 特殊不加密API
+	req.isEncrypt = false
+{{- end}}
+	return req
+}
+
+func (r {{.Name}}Req) GetUrl() (*url.URL, error) {
+	return parseModelUrl({{.PathConst}})
+}
+
+type {{.Name}}Resp struct {
+{{- range .Response}}
+	{{.Name}} {{.Type}} {{tag .}}{{if .Doc}} // {{.Doc}}{{end}}
+{{- end}}
+}
+
+{{end}}
+{{- if .Endpoints}}
+func init() {
+{{- range .Endpoints}}
+	RegisterEndpoint[{{.Name}}Resp](func() IRequest { r := New{{.Name}}Req(); return &r })
+{{- end}}
+}
+{{- end}}
+`))
+
+var clientTmpl = template.Must(template.New("client").Parse(header + `package core
+
+import (
+	"context"
+	"gopcr/models"
+)
+{{range .Endpoints}}
+// {{.Name}} 调用 {{.Path}}{{if .Doc}}，{{.Doc}}{{end}}
+func (c *Client) {{.Name}}(ctx context.Context, req *models.{{.Name}}Req) (*models.BaseResponse[models.{{.Name}}Resp], error) {
+	return CallResponse[*models.{{.Name}}Req, models.{{.Name}}Resp](ctx, c, req)
+}
+{{end}}`))
+
+func generateModels(spec *Spec) ([]byte, error) {
+	return execute(modelsTmpl, spec)
+}
+
+func generateClient(spec *Spec) ([]byte, error) {
+	return execute(clientTmpl, spec)
+}
+
+func execute(tmpl *template.Template, spec *Spec) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, spec); err != nil {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("生成的代码无法格式化: %w\n%s", err, buf.Bytes())
+	}
+	return code, nil
+}
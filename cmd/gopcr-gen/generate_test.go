@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "用生成结果更新 testdata 中的golden文件")
+
+func TestGenerateGolden(t *testing.T) {
+	// endpoints.yaml 与 models/endpoints.yaml 格式相同；features.json 覆盖不加密接口、默认值和JSON格式
+	for spec, prefix := range map[string]string{
+		"endpoints.yaml": "",
+		"features.json":  "features_",
+	} {
+		spec, err := loadSpec(filepath.Join("testdata", spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for golden, generate := range map[string]func(*Spec) ([]byte, error){
+			prefix + "models.golden": generateModels,
+			prefix + "client.golden": generateClient,
+		} {
+			t.Run(golden, func(t *testing.T) {
+				got, err := generate(spec)
+				if err != nil {
+					t.Fatal(err)
+				}
+				path := filepath.Join("testdata", golden)
+				if *update {
+					if err = os.WriteFile(path, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("生成结果与 %s 不一致，使用 -update 更新\n--- got\n%s", path, got)
+				}
+			})
+		}
+	}
+}
+
+func TestLoadSpecErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		spec string
+		want []string
+	}{
+		{
+			name: "unknown key",
+			spec: "package: other\nendpoints: []\n",
+			want: []string{"field package not found"},
+		},
+		{
+			name: "invalid names",
+			spec: `
+types:
+  - {name: lower}
+endpoints:
+  - {name: Bad-Name, path: a/b}
+  - name: Good
+    request:
+      - {name: x, type: int, json: x}
+      - {name: NoType, json: no_type}
+      - {name: NoJSON, type: int}
+`,
+			want: []string{
+				`无效的类型名: "lower"`,
+				`无效的接口名: "Bad-Name"`,
+				"Good: 缺少path",
+				`GoodReq: 无效的字段名: "x"`,
+				"GoodReq.NoType: 缺少type",
+				"GoodReq.NoJSON: 缺少json",
+			},
+		},
+		{
+			name: "duplicates",
+			spec: `
+types:
+  - {name: AReq}
+endpoints:
+  - name: A
+    path: a/b
+    response:
+      - {name: X, type: int, json: x}
+      - {name: X, type: int, json: y}
+  - {name: B, path: a/b}
+`,
+			want: []string{"重复的类型名: AReq", "AResp: 重复的字段: X", "B: 重复的path: a/b"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "spec.yaml")
+			if err := os.WriteFile(path, []byte(tc.spec), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadSpec(path)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// gopcr-gen 根据接口描述文件（YAML或JSON）生成请求/响应模型和 core.Client 方法。
+//
+// 用法:
+//
+//	//go:generate go run gopcr/cmd/gopcr-gen -spec endpoints.yaml -models endpoints_gen.go -client ../core/endpoints_gen.go
+//
+// 使用 -check 时不写入文件，生成结果与已有文件不一致时返回非0，可用于检查生成的代码是否最新
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	specPath := flag.String("spec", "", "接口描述文件(YAML或JSON)")
+	modelsOut := flag.String("models", "", "模型代码的输出文件")
+	clientOut := flag.String("client", "", "Client方法的输出文件，为空时不生成")
+	check := flag.Bool("check", false, "只检查输出文件是否与生成结果一致")
+	flag.Parse()
+
+	if *specPath == "" || *modelsOut == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*specPath, *modelsOut, *clientOut, *check); err != nil {
+		fmt.Fprintln(os.Stderr, "gopcr-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, modelsOut, clientOut string, check bool) error {
+	spec, err := loadSpec(specPath)
+	if err != nil {
+		return err
+	}
+
+	outputs := map[string]func(*Spec) ([]byte, error){modelsOut: generateModels}
+	if clientOut != "" {
+		outputs[clientOut] = generateClient
+	}
+	for path, generate := range outputs {
+		code, err := generate(spec)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if check {
+			old, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(old, code) {
+				return fmt.Errorf("%s 不是最新的生成结果，请重新运行 go generate", path)
+			}
+			continue
+		}
+		if err = os.WriteFile(path, code, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
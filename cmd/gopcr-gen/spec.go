@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec 接口描述文件。生成的模型使用 models 包的未导出成员，只能生成到 models 包
+type Spec struct {
+	Types     []Type     `yaml:"types"`     // 接口中用到的其他结构体
+	Endpoints []Endpoint `yaml:"endpoints"` // 接口列表
+}
+
+// Type 结构体定义
+type Type struct {
+	Name   string  `yaml:"name"`
+	Doc    string  `yaml:"doc"`
+	Fields []Field `yaml:"fields"`
+}
+
+// Endpoint 接口定义
+type Endpoint struct {
+	Name     string  `yaml:"name"` // 生成 NameReq、NameResp、NewNameReq 和 Client.Name
+	Path     string  `yaml:"path"`
+	Doc      string  `yaml:"doc"`
+	Encrypt  *bool   `yaml:"encrypt"` // 默认为true
+	Request  []Field `yaml:"request"`
+	Response []Field `yaml:"response"`
+}
+
+// Field 结构体字段
+type Field struct {
+	Name    string `yaml:"name"`
+	Type    string `yaml:"type"`    // Go类型，例如 int、[]int、ProfileUserInfo
+	JSON    string `yaml:"json"`    // json标签
+	Doc     string `yaml:"doc"`     // 行尾注释
+	Default string `yaml:"default"` // NewXxxReq中的默认值（Go表达式），仅用于请求字段
+}
+
+// Encrypted 接口是否加密
+func (e Endpoint) Encrypted() bool {
+	return e.Encrypt == nil || *e.Encrypt
+}
+
+// PathConst 路径常量名
+func (e Endpoint) PathConst() string {
+	return strings.ToLower(e.Name[:1]) + e.Name[1:] + "ReqPath"
+}
+
+// loadSpec 读取并校验描述文件，JSON是YAML的子集，使用同一个解析器。
+// 未知的键视为错误
+func loadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+	if err = spec.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+func (s *Spec) validate() error {
+	var errs []error
+	names := make(map[string]bool)
+	paths := make(map[string]bool)
+	declare := func(name string) {
+		if names[name] {
+			errs = append(errs, fmt.Errorf("重复的类型名: %s", name))
+		}
+		names[name] = true
+	}
+
+	for _, t := range s.Types {
+		if !token.IsIdentifier(t.Name) || !token.IsExported(t.Name) {
+			errs = append(errs, fmt.Errorf("无效的类型名: %q", t.Name))
+			continue
+		}
+		declare(t.Name)
+		errs = append(errs, validateFields(t.Name, t.Fields)...)
+	}
+	for _, e := range s.Endpoints {
+		if !token.IsIdentifier(e.Name) || !token.IsExported(e.Name) {
+			errs = append(errs, fmt.Errorf("无效的接口名: %q", e.Name))
+			continue
+		}
+		if e.Path == "" {
+			errs = append(errs, fmt.Errorf("%s: 缺少path", e.Name))
+		} else if paths[e.Path] {
+			errs = append(errs, fmt.Errorf("%s: 重复的path: %s", e.Name, e.Path))
+		}
+		paths[e.Path] = true
+		declare(e.Name + "Req")
+		declare(e.Name + "Resp")
+		errs = append(errs, validateFields(e.Name+"Req", e.Request)...)
+		errs = append(errs, validateFields(e.Name+"Resp", e.Response)...)
+	}
+	return errors.Join(errs...)
+}
+
+func validateFields(owner string, fields []Field) []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		switch {
+		case !token.IsIdentifier(f.Name) || !token.IsExported(f.Name):
+			errs = append(errs, fmt.Errorf("%s: 无效的字段名: %q", owner, f.Name))
+		case f.Type == "":
+			errs = append(errs, fmt.Errorf("%s.%s: 缺少type", owner, f.Name))
+		case f.JSON == "":
+			errs = append(errs, fmt.Errorf("%s.%s: 缺少json", owner, f.Name))
+		case seen[f.Name]:
+			errs = append(errs, fmt.Errorf("%s: 重复的字段: %s", owner, f.Name))
+		}
+		seen[f.Name] = true
+	}
+	return errs
+}
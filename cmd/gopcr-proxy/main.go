@@ -0,0 +1,85 @@
+// gopcr-proxy 拦截官方客户端与游戏服务器之间的流量并解密，用于了解新的接口。
+//
+// 用法:
+//
+//	gopcr-proxy -listen :8080 -record traffic.jsonl -spec candidates.yaml
+//
+// 首次运行时在 -ca 目录下生成CA证书 ca.pem，需要安装到运行游戏的设备上，
+// 并将设备的HTTP代理设置为本程序的监听地址。-intercept 中的主机会被解密，其他主机直接转发。
+// -record 的输出格式与 core.NewJSONLRecorder 相同，可以用 core.NewReplayTransport 回放
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"gopcr/core"
+	"gopcr/log"
+	"gopcr/pcrcrypto"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	listen := flag.String("listen", ":8080", "监听地址")
+	caDir := flag.String("ca", "gopcr-proxy-ca", "CA证书目录，不存在时生成")
+	intercept := flag.String("intercept", "bilibiligame.net", "解密的主机（后缀匹配），逗号分隔")
+	recordPath := flag.String("record", "", "以JSONL格式记录解密的流量")
+	specPath := flag.String("spec", "", "生成未建模接口的候选描述文件（gopcr-gen格式）")
+	verbose := flag.Bool("v", false, "在日志中输出解密的请求和响应")
+	insecure := flag.Bool("insecure", false, "不校验上游服务器的证书")
+	flag.Parse()
+
+	ca, err := loadOrCreateCA(*caDir)
+	if err != nil {
+		fatal(err)
+	}
+	p := &proxy{
+		ca:        ca,
+		intercept: splitList(*intercept),
+		codec:     pcrcrypto.New(),
+		verbose:   *verbose,
+		upstream: &http.Transport{
+			Proxy:               nil,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: *insecure},
+			TLSHandshakeTimeout: 10 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	if *recordPath != "" {
+		f, err := os.OpenFile(*recordPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fatal(err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		p.recorder = core.NewJSONLRecorder(f)
+	}
+	if *specPath != "" {
+		p.spec = newSpecWriter(*specPath)
+	}
+
+	log.Info("gopcr-proxy 监听 %s，解密 %s", *listen, strings.Join(p.intercept, ", "))
+	srv := &http.Server{Addr: *listen, Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	if err = srv.ListenAndServe(); err != nil {
+		fatal(err)
+	}
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "gopcr-proxy:", err)
+	os.Exit(1)
+}
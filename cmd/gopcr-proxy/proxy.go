@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"gopcr/core"
+	"gopcr/log"
+	"gopcr/models"
+	"gopcr/pcrcrypto"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hopHeaders 不转发的逐跳头
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// proxy 拦截并解密游戏流量的HTTP(S)代理
+type proxy struct {
+	ca        *certAuthority
+	intercept []string // 拦截的主机后缀
+	upstream  http.RoundTripper
+	codec     *pcrcrypto.Codec
+	recorder  core.Recorder // 为nil时不记录
+	spec      *specWriter   // 为nil时不生成
+	verbose   bool          // 是否在日志中输出明文
+}
+
+// shouldIntercept 是否解密发往host的流量
+func (p *proxy) shouldIntercept(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, suffix := range p.intercept {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.handleConnect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "gopcr-proxy只接受代理请求", http.StatusBadRequest)
+		return
+	}
+	p.forward(w, r)
+}
+
+// handleConnect 处理HTTPS代理。拦截的主机使用CA签发的证书进行中间人解密，其他主机直接转发
+func (p *proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "不支持CONNECT", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		log.Error("CONNECT %s 失败: %v", r.Host, err)
+		return
+	}
+	if _, err = io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	if !p.shouldIntercept(r.Host) {
+		p.tunnel(conn, r.Host)
+		return
+	}
+
+	hostname := r.Host
+	if h, _, err := net.SplitHostPort(r.Host); err == nil {
+		hostname = h
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return p.ca.leaf(hostname)
+		},
+	})
+	if err = tlsConn.Handshake(); err != nil {
+		log.Error("与客户端TLS握手失败 %s: %v", r.Host, err)
+		_ = conn.Close()
+		return
+	}
+
+	host := r.Host
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			req.URL.Scheme = "https"
+			req.URL.Host = host
+			p.forward(w, req)
+		}),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	_ = srv.Serve(&singleConnListener{conn: tlsConn})
+}
+
+// tunnel 不拦截的主机直接转发TCP流量
+func (p *proxy) tunnel(client net.Conn, host string) {
+	server, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		log.Error("连接 %s 失败: %v", host, err)
+		_ = client.Close()
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	pipe := func(dst, src net.Conn) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+		_ = dst.Close()
+	}
+	go pipe(server, client)
+	go pipe(client, server)
+	wg.Wait()
+}
+
+// forward 将请求转发给服务器，拦截的主机同时解密记录请求和响应
+func (p *proxy) forward(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out.Header = r.Header.Clone()
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+
+	resp, err := p.upstream.RoundTrip(out)
+	if err != nil {
+		log.Error("%s %s 转发失败: %v", r.Method, r.URL, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	for _, h := range hopHeaders {
+		w.Header().Del(h)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(respBody)
+
+	if p.shouldIntercept(r.URL.Host) {
+		p.inspect(out, reqBody, resp, respBody, start)
+	}
+}
+
+// inspect 解密一次请求和响应，输出日志并交给recorder和spec
+func (p *proxy) inspect(r *http.Request, reqBody []byte, resp *http.Response, respBody []byte, start time.Time) {
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		if plain, err := gunzip(respBody); err == nil {
+			respBody = plain
+		}
+	}
+
+	rec := &core.Record{
+		Time:           start,
+		DurationMs:     float64(time.Since(start).Microseconds()) / 1000,
+		Method:         r.Method,
+		Path:           strings.TrimPrefix(r.URL.Path, "/"),
+		RequestHeaders: log.RedactHeaders(r.Header),
+		Status:         resp.StatusCode,
+	}
+
+	var request map[string]any
+	if err := p.codec.DecryptBody(reqBody, &request); err == nil {
+		rec.Encrypted = true
+	} else if err = json.Unmarshal(reqBody, &request); err != nil {
+		request = nil
+	}
+	if request != nil {
+		rec.Request = log.RedactValue(request)
+	}
+
+	var response map[string]any
+	if rec.Encrypted {
+		if err := p.codec.DecryptBody(respBody, &response); err != nil {
+			rec.Error = "响应解密失败: " + err.Error()
+		}
+	} else if err := json.Unmarshal(respBody, &response); err != nil {
+		response = nil
+	}
+	if response != nil {
+		rec.Response = log.RedactValue(response).(map[string]any)
+	}
+	rec.ResultCode = models.RawResponse(response).GetResultCode()
+
+	log.Info("%s %s HTTP %d result_code=%d %.1fms", rec.Method, rec.Path, rec.Status, rec.ResultCode, rec.DurationMs)
+	if p.verbose {
+		// 与记录一样输出脱敏后的明文
+		if body, err := json.MarshalIndent(map[string]any{"request": rec.Request, "response": rec.Response}, "", "  "); err == nil {
+			log.Info("%s\n%s", rec.Path, body)
+		}
+	}
+	if p.recorder != nil {
+		p.recorder.Record(rec)
+	}
+	if p.spec != nil && request != nil && response != nil {
+		p.spec.observe(rec.Path, rec.Encrypted, request, models.RawResponse(response).Data())
+	}
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+// singleConnListener 只返回一个连接的 net.Listener，用于在劫持的连接上运行 http.Server
+type singleConnListener struct {
+	conn net.Conn
+	once sync.Once
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() {
+		conn = l.conn
+	})
+	if conn == nil {
+		return nil, net.ErrClosed
+	}
+	return conn, nil
+}
+
+func (l *singleConnListener) Close() error {
+	return nil
+}
+
+func (l *singleConnListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"gopcr/config"
+	"gopcr/core"
+	"gopcr/mockserver"
+	"gopcr/models"
+	"gopcr/pcrcrypto"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// memoryRecorder 保存代理记录的请求
+type memoryRecorder struct {
+	mu      sync.Mutex
+	records []*core.Record
+}
+
+func (r *memoryRecorder) Record(rec *core.Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+}
+
+// find 返回path的最后一条记录
+func (r *memoryRecorder) find(path string) *core.Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.records) - 1; i >= 0; i-- {
+		if r.records[i].Path == path {
+			return r.records[i]
+		}
+	}
+	return nil
+}
+
+// newTestProxy 启动拦截127.0.0.1的代理
+func newTestProxy(t *testing.T, recorder core.Recorder) (*proxy, *httptest.Server) {
+	t.Helper()
+	ca, err := loadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &proxy{
+		ca:        ca,
+		intercept: []string{"127.0.0.1"},
+		codec:     pcrcrypto.New(),
+		recorder:  recorder,
+		upstream: &http.Transport{
+			// 上游是测试用的自签名证书
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	return p, srv
+}
+
+// callThroughProxy 通过代理登录并调用 home/index
+func callThroughProxy(t *testing.T, baseURL string, transport *http.Transport) {
+	t.Helper()
+	c, err := core.NewClient(context.Background(),
+		core.SdkAccount{Uid: "u1", AccessKey: "key", Platform: "2", Channel: "1"},
+		core.WithConfig(config.NewBili()), core.WithHosts(baseURL), core.WithTransport(transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkHomeIndexRecord 检查代理解密的 home/index 请求和响应
+func checkHomeIndexRecord(t *testing.T, recorder *memoryRecorder) {
+	t.Helper()
+	rec := recorder.find(mockserver.EndpointHomeIndex)
+	if rec == nil {
+		t.Fatal("home/index was not recorded")
+	}
+	if !rec.Encrypted || rec.Error != "" {
+		t.Fatalf("record: encrypted=%v error=%q", rec.Encrypted, rec.Error)
+	}
+	request, ok := rec.Request.(map[string]any)
+	if !ok || request["viewer_id"] == nil || request["is_first"] == nil {
+		t.Fatalf("request was not decrypted: %#v", rec.Request)
+	}
+	if rec.ResultCode != models.ResultCodeSuccess {
+		t.Fatalf("result code %d", rec.ResultCode)
+	}
+	if _, ok = models.RawResponse(rec.Response).Data()["daily_reset_time"]; !ok {
+		t.Fatalf("response was not decrypted: %#v", rec.Response)
+	}
+	if request["viewer_id"] != "[REDACTED]" {
+		t.Fatalf("encrypted viewer_id recorded as %v", request["viewer_id"])
+	}
+	for _, name := range []string{"Sid", "Request-Id"} {
+		if v := rec.RequestHeaders[name]; v != "[REDACTED]" {
+			t.Fatalf("header %s recorded as %q", name, v)
+		}
+	}
+}
+
+func TestProxyForward(t *testing.T) {
+	mock := mockserver.New()
+	defer mock.Close()
+	recorder := &memoryRecorder{}
+	_, proxySrv := newTestProxy(t, recorder)
+
+	proxyURL, _ := url.Parse(proxySrv.URL)
+	callThroughProxy(t, mock.URL, &http.Transport{Proxy: http.ProxyURL(proxyURL)})
+
+	checkHomeIndexRecord(t, recorder)
+	if errs := mock.Errors(); len(errs) != 0 {
+		t.Fatalf("mock server errors: %v", errs)
+	}
+}
+
+func TestProxyConnect(t *testing.T) {
+	mock := mockserver.New()
+	defer mock.Close()
+	// 在模拟服务器前加一层HTTPS，使客户端通过CONNECT访问
+	mockURL, _ := url.Parse(mock.URL)
+	upstream := httptest.NewTLSServer(httputil.NewSingleHostReverseProxy(mockURL))
+	defer upstream.Close()
+
+	recorder := &memoryRecorder{}
+	p, proxySrv := newTestProxy(t, recorder)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(p.ca.cert)
+	proxyURL, _ := url.Parse(proxySrv.URL)
+	callThroughProxy(t, upstream.URL, &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	})
+
+	checkHomeIndexRecord(t, recorder)
+	if errs := mock.Errors(); len(errs) != 0 {
+		t.Fatalf("mock server errors: %v", errs)
+	}
+}
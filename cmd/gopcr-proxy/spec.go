@@ -0,0 +1,189 @@
+package main
+
+import (
+	"gopcr/log"
+	"gopcr/models"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// specField 与 gopcr-gen 描述文件的字段格式一致
+type specField struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	JSON string `yaml:"json"`
+}
+
+type specEndpoint struct {
+	Name     string      `yaml:"name"`
+	Path     string      `yaml:"path"`
+	Encrypt  *bool       `yaml:"encrypt,omitempty"`
+	Request  []specField `yaml:"request,omitempty"`
+	Response []specField `yaml:"response,omitempty"`
+}
+
+// observedEndpoint 观察到的接口，字段名到推断的Go类型
+type observedEndpoint struct {
+	encrypted bool
+	request   map[string]string
+	response  map[string]string
+}
+
+// specWriter 根据观察到的流量生成 gopcr-gen 的候选描述文件。
+// 跳过已注册（已建模）的接口，每次发现新字段时重写文件
+type specWriter struct {
+	path string
+
+	mu        sync.Mutex
+	endpoints map[string]*observedEndpoint
+}
+
+func newSpecWriter(path string) *specWriter {
+	return &specWriter{path: path, endpoints: make(map[string]*observedEndpoint)}
+}
+
+func (w *specWriter) observe(path string, encrypted bool, request, data map[string]any) {
+	if _, ok := models.LookupEndpoint(path); ok {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	e, ok := w.endpoints[path]
+	if !ok {
+		e = &observedEndpoint{encrypted: encrypted, request: map[string]string{}, response: map[string]string{}}
+		w.endpoints[path] = e
+	}
+	changed := !ok
+	for k, v := range request {
+		if k == "viewer_id" {
+			continue
+		}
+		changed = mergeField(e.request, k, v) || changed
+	}
+	for k, v := range data {
+		changed = mergeField(e.response, k, v) || changed
+	}
+	if !changed {
+		return
+	}
+	if err := w.write(); err != nil {
+		log.Error("写入 %s 失败: %v", w.path, err)
+	}
+}
+
+// mergeField 记录字段类型，多次观察到的类型不一致时使用any。返回是否有变化
+func mergeField(fields map[string]string, key string, value any) bool {
+	typ := goType(value)
+	if value == nil {
+		// nil值没有类型信息，之后观察到非nil值时再确定
+		typ = ""
+	}
+	old, ok := fields[key]
+	switch {
+	case !ok || old == "":
+		fields[key] = typ
+	case old == typ || old == "any" || typ == "":
+		return false
+	case old == "int" && typ == "float64":
+		fields[key] = typ
+	case old == "float64" && typ == "int":
+		return false
+	default:
+		fields[key] = "any"
+	}
+	return true
+}
+
+// goType 推断msgpack/JSON值对应的Go类型
+func goType(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "int"
+	case float32, float64:
+		return "float64"
+	case []byte:
+		return "[]byte"
+	case []any:
+		if len(v) == 0 {
+			return "[]any"
+		}
+		return "[]" + goType(v[0])
+	case map[string]any:
+		return "map[string]any"
+	}
+	return "any"
+}
+
+// write 按路径排序写出描述文件，调用方需持有锁
+func (w *specWriter) write() error {
+	paths := make([]string, 0, len(w.endpoints))
+	for path := range w.endpoints {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var spec struct {
+		Endpoints []specEndpoint `yaml:"endpoints"`
+	}
+	for _, path := range paths {
+		e := w.endpoints[path]
+		endpoint := specEndpoint{
+			Name:     goName(strings.ReplaceAll(path, "/", "_")),
+			Path:     path,
+			Request:  specFields(e.request),
+			Response: specFields(e.response),
+		}
+		if !e.encrypted {
+			endpoint.Encrypt = new(bool)
+		}
+		spec.Endpoints = append(spec.Endpoints, endpoint)
+	}
+
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	header := []byte("# 由 gopcr-proxy 根据观察到的流量生成的候选描述文件，类型为推断结果，使用前请检查\n")
+	return os.WriteFile(w.path, append(header, data...), 0o644)
+}
+
+func specFields(fields map[string]string) []specField {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([]specField, 0, len(keys))
+	for _, k := range keys {
+		typ := fields[k]
+		if typ == "" {
+			typ = "any"
+		}
+		list = append(list, specField{Name: goName(k), Type: typ, JSON: k})
+	}
+	return list
+}
+
+// goName 将snake_case转为Go的导出名，例如 target_viewer_id → TargetViewerId
+func goName(s string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	name := b.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "F" + name
+	}
+	return name
+}
@@ -0,0 +1,107 @@
+// pcrdecode 解密抓包得到的游戏请求体/响应体并输出JSON，或将JSON加密为请求体/响应体。
+//
+// 用法:
+//
+//	pcrdecode [-viewer-id] [file...]        解密（自动识别原始字节和Base64），输出格式化的JSON
+//	pcrdecode -e [-b64] [-o out] [file]     将JSON加密为请求体（原始字节），-b64时输出响应体格式
+//
+// 未指定文件时从标准输入读取
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gopcr/pcrcrypto"
+	"io"
+	"os"
+)
+
+func main() {
+	encrypt := flag.Bool("e", false, "将JSON加密")
+	b64 := flag.Bool("b64", false, "加密时输出Base64（响应体格式）")
+	viewerId := flag.Bool("viewer-id", false, "解密时同时解密viewer_id字段")
+	output := flag.String("o", "", "输出文件，默认为标准输出")
+	flag.Parse()
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fatal(err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				fatal(err)
+			}
+		}()
+		out = f
+	}
+
+	inputs := flag.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	codec := pcrcrypto.New()
+	for _, name := range inputs {
+		data, err := readInput(name)
+		if err != nil {
+			fatal(err)
+		}
+		if *encrypt {
+			err = encryptJSON(codec, out, data, *b64)
+		} else {
+			err = decryptBody(codec, out, data, *viewerId)
+		}
+		if err != nil {
+			fatal(fmt.Errorf("%s: %w", name, err))
+		}
+	}
+}
+
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func decryptBody(codec *pcrcrypto.Codec, out io.Writer, data []byte, decodeViewerId bool) error {
+	var v any
+	if err := codec.DecryptBody(data, &v); err != nil {
+		return fmt.Errorf("解密失败: %w", err)
+	}
+	if m, ok := v.(map[string]any); ok && decodeViewerId {
+		if encoded, ok := m["viewer_id"].(string); ok {
+			if id, err := codec.DecryptViewerId(encoded); err == nil {
+				m["viewer_id"] = id
+			}
+		}
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
+
+func encryptJSON(codec *pcrcrypto.Codec, out io.Writer, data []byte, b64 bool) error {
+	v, err := pcrcrypto.UnmarshalJSON(data)
+	if err != nil {
+		return fmt.Errorf("无效的JSON: %w", err)
+	}
+	body, err := codec.EncryptData(v)
+	if err != nil {
+		return fmt.Errorf("加密失败: %w", err)
+	}
+	if b64 {
+		body = []byte(base64.StdEncoding.EncodeToString(body) + "\n")
+	}
+	_, err = out.Write(body)
+	return err
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "pcrdecode:", err)
+	os.Exit(1)
+}
@@ -20,17 +20,19 @@ const (
 const (
 	// DefaultAppVer 默认App版本
 	DefaultAppVer = "8.1.0"
+	// DefaultUnityVersion 游戏客户端使用的Unity版本
+	DefaultUnityVersion = "2021.3.36f1c1"
 	// BiliResKey b服reskey
 	biliResKey    = "ab00a0a6dd915a052a2ef7fd649083e5"
 	channelResKey = "d145b29050641dac2f8b19df0afe0e59"
 )
 
+// getDefaultHeaders 默认请求头，APP-VER、RES-VER、RES-KEY由 Config 单独管理
 func getDefaultHeaders() map[string]string {
 	return map[string]string{
 		"Accept-Encoding":      "deflate, gzip",
-		"User-Agent":           "UnityPlayer/2021.3.36f1c1 (UnityWebRequest/1.0, libcurl/8.5.0-DEV)",
-		"X-Unity-Version":      "2021.3.36f1c1",
-		"APP-VER":              GetInstance().GetOptVal(AppVer).(string),
+		"User-Agent":           UnityUserAgent(DefaultUnityVersion),
+		"X-Unity-Version":      DefaultUnityVersion,
 		"BATTLE-LOGIC-VERSION": "4",
 		"DEVICE":               "2",
 		"DEVICE-ID":            "ln-nmsl",
@@ -41,19 +43,21 @@ func getDefaultHeaders() map[string]string {
 		"LOCALE":               "Jpn",
 		"PLATFORM-OS-VERSION":  "RedStar OS - GoPcr",
 		"REGION-CODE":          "CN",
-		"RES-VER":              "10002200",
 		"SHORT-UDID":           "0",
 	}
 }
 
+// GetBiliHeaders B服默认配置的请求头
 func GetBiliHeaders() map[string]string {
-	defaultHeaders := getDefaultHeaders()
-	defaultHeaders["RES-KEY"] = biliResKey
-	return defaultHeaders
+	return Default().Headers()
 }
 
+// GetChannelHeaders 渠道服默认配置的请求头
 func GetChannelHeaders() map[string]string {
-	defaultHeaders := getDefaultHeaders()
-	defaultHeaders["RES-KEY"] = channelResKey
-	return defaultHeaders
+	return DefaultChannel().Headers()
+}
+
+// UnityUserAgent Unity客户端的User-Agent
+func UnityUserAgent(unityVersion string) string {
+	return "UnityPlayer/" + unityVersion + " (UnityWebRequest/1.0, libcurl/8.5.0-DEV)"
 }
@@ -0,0 +1,433 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopcr/log"
+	"gopkg.in/yaml.v3"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvPrefix 覆盖配置文件的环境变量前缀。
+// 变量名由字段名转为大写得到，例如 GOPCR_TIMEOUT、GOPCR_ACCOUNTS_0_ACCESS_KEY
+const EnvPrefix = "GOPCR_"
+
+// 账号所在的服务器
+const (
+	ServerBili    = "bili"    // B服
+	ServerChannel = "channel" // 渠道服
+)
+
+// File 配置文件的内容，由 Load 读取
+type File struct {
+	LogLevel          string    `json:"log_level" yaml:"log_level" toml:"log_level"`                            // debug、info、warn、error
+	Timeout           Duration  `json:"timeout" yaml:"timeout" toml:"timeout"`                                  // 单次请求的超时时间，例如 "10s"，未配置时使用默认值
+	AppVer            string    `json:"app_ver" yaml:"app_ver" toml:"app_ver"`                                  // 初始APP-VER
+	Concurrency       int       `json:"concurrency" yaml:"concurrency" toml:"concurrency"`                      // 同时执行任务的账号数
+	ServerConcurrency int       `json:"server_concurrency" yaml:"server_concurrency" toml:"server_concurrency"` // 每个服务器同时执行任务的账号数
+	Accounts          []Account `json:"accounts" yaml:"accounts" toml:"accounts"`
+
+	mu      sync.Mutex
+	configs map[string]*Config
+}
+
+// Account 配置文件中的账号
+type Account struct {
+	Uid       string `json:"uid" yaml:"uid" toml:"uid"`
+	AccessKey string `json:"access_key" yaml:"access_key" toml:"access_key"`
+	Platform  string `json:"platform" yaml:"platform" toml:"platform"` // 默认 "2"
+	Channel   string `json:"channel" yaml:"channel" toml:"channel"`    // 默认 "1"
+	Server    string `json:"server" yaml:"server" toml:"server"`       // bili（默认）或 channel
+	Proxy     string `json:"proxy" yaml:"proxy" toml:"proxy"`          // 代理地址，支持http、https、socks5
+	Device    Device `json:"device" yaml:"device" toml:"device"`
+}
+
+// Device 账号使用的设备。未配置时使用 Config 中所有账号相同的默认设备。
+// 指定seed时由seed生成设备，每个账号的设备不同；指定file时生成的设备保存到该文件，
+// 之后从文件读取，此时seed为空则使用uid。其余不为空的字段覆盖生成的值；
+// 只指定这些字段时不生成设备，只覆盖默认设备的对应请求头
+type Device struct {
+	Seed      string `json:"seed" yaml:"seed" toml:"seed"`
+	File      string `json:"file" yaml:"file" toml:"file"`
+	Id        string `json:"id" yaml:"id" toml:"id"`                         // DEVICE-ID
+	Model     string `json:"model" yaml:"model" toml:"model"`                // DEVICE-NAME
+	OSVersion string `json:"os_version" yaml:"os_version" toml:"os_version"` // PLATFORM-OS-VERSION
+	GPU       string `json:"gpu" yaml:"gpu" toml:"gpu"`                      // GRAPHICS-DEVICE-NAME
+	Carrier   string `json:"carrier" yaml:"carrier" toml:"carrier"`          // load/index 的运营商
+}
+
+// Duration 可以从 "5s"、"1m30s" 等字符串读取的时间间隔
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// FieldError 配置项无效，Field为配置文件中的字段路径，例如 accounts[1].access_key
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("配置项 %s: %s", e.Field, e.Message)
+}
+
+// Load 读取配置文件，按扩展名识别格式（.yaml/.yml、.toml、.json），
+// 应用环境变量覆盖（见 EnvPrefix）、填充默认值并校验。
+// 未知字段和无效的值返回 *FieldError（TOML的类型错误除外），多个错误由 errors.Join 合并
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if err = decodeFile(filepath.Ext(path), data, f); err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	if err = applyEnv(EnvPrefix, reflect.ValueOf(f).Elem(), ""); err != nil {
+		return nil, err
+	}
+	f.setDefaults()
+	if err = f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// decodeFile 按格式解码，不允许未知字段
+func decodeFile(ext string, data []byte, f *File) error {
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		var raw any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		if err := checkFields(reflect.TypeOf(f).Elem(), raw, "", "yaml", yamlConvert); err != nil {
+			return err
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	case ".toml":
+		meta, err := toml.Decode(string(data), f)
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, key := range meta.Undecoded() {
+			errs = append(errs, &FieldError{Field: key.String(), Message: "未知字段"})
+		}
+		return errors.Join(errs...)
+	case ".json":
+		var raw any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		if err := checkFields(reflect.TypeOf(f).Elem(), raw, "", "json", jsonConvert); err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		return dec.Decode(f)
+	}
+	return fmt.Errorf("不支持的配置文件格式: %q", ext)
+}
+
+// checkFields 对照类型t检查解码得到的通用值raw，未知字段和类型不符的值返回带路径的 *FieldError。
+// tag为字段名所在的标签，convert将单个值解码到目标类型
+func checkFields(t reflect.Type, raw any, path, tag string, convert func(raw, target any) error) error {
+	if raw == nil {
+		return nil
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return &FieldError{Field: cmp.Or(path, "(root)"), Message: "应为对象"}
+	}
+	fields := make(map[string]reflect.StructField)
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if sf.IsExported() {
+			fields[strings.Split(sf.Tag.Get(tag), ",")[0]] = sf
+		}
+	}
+
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		field := key
+		if path != "" {
+			field = path + "." + key
+		}
+		sf, ok := fields[key]
+		if !ok {
+			errs = append(errs, &FieldError{Field: field, Message: "未知字段"})
+			continue
+		}
+		ft := sf.Type
+		switch {
+		case ft.Kind() == reflect.Struct && !reflect.PointerTo(ft).Implements(textUnmarshalerType):
+			errs = append(errs, checkFields(ft, m[key], field, tag, convert))
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+			if m[key] == nil {
+				continue
+			}
+			items, ok := m[key].([]any)
+			if !ok {
+				errs = append(errs, &FieldError{Field: field, Message: "应为列表"})
+				continue
+			}
+			for j, item := range items {
+				errs = append(errs, checkFields(ft.Elem(), item, fmt.Sprintf("%s[%d]", field, j), tag, convert))
+			}
+		default:
+			if err := convert(m[key], reflect.New(ft).Interface()); err != nil {
+				errs = append(errs, &FieldError{Field: field, Message: err.Error()})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func yamlConvert(raw, target any) error {
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	err = yaml.Unmarshal(data, target)
+	// 单个值的行号没有意义，只保留错误描述
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		msg := typeErr.Errors[0]
+		if _, after, ok := strings.Cut(msg, ": "); ok && strings.HasPrefix(msg, "line ") {
+			msg = after
+		}
+		return errors.New(msg)
+	}
+	return err
+}
+
+func jsonConvert(raw, target any) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// applyEnv 用环境变量覆盖v中的字段。field为当前字段路径，用于错误信息
+func applyEnv(prefix string, v reflect.Value, field string) error {
+	var errs []error
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		path := name
+		if field != "" {
+			path = field + "." + name
+		}
+		env := prefix + strings.ToUpper(name)
+		fv := v.Field(i)
+
+		switch {
+		case fv.Kind() == reflect.Struct:
+			errs = append(errs, applyEnv(env+"_", fv, path))
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			// 列表按下标覆盖，例如 GOPCR_ACCOUNTS_0_UID，超出长度时追加
+			for j := 0; ; j++ {
+				itemPrefix := env + "_" + strconv.Itoa(j) + "_"
+				if j >= fv.Len() {
+					if !hasEnvPrefix(itemPrefix) {
+						break
+					}
+					fv.Set(reflect.Append(fv, reflect.Zero(fv.Type().Elem())))
+				}
+				errs = append(errs, applyEnv(itemPrefix, fv.Index(j), fmt.Sprintf("%s[%d]", path, j)))
+			}
+		default:
+			value, ok := os.LookupEnv(env)
+			if !ok {
+				continue
+			}
+			if err := setField(fv, value); err != nil {
+				errs = append(errs, &FieldError{Field: path, Message: fmt.Sprintf("环境变量%s无效: %v", env, err)})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasEnvPrefix 是否存在以prefix开头的环境变量
+func hasEnvPrefix(prefix string) bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setField 将环境变量的值写入字段
+func setField(fv reflect.Value, value string) error {
+	if u, ok := fv.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(n))
+	default:
+		return fmt.Errorf("不支持的类型 %s", fv.Type())
+	}
+	return nil
+}
+
+// setDefaults 填充账号的默认值
+func (f *File) setDefaults() {
+	for i := range f.Accounts {
+		a := &f.Accounts[i]
+		if a.Platform == "" {
+			a.Platform = "2"
+		}
+		if a.Channel == "" {
+			a.Channel = "1"
+		}
+		if a.Server == "" {
+			a.Server = ServerBili
+		}
+	}
+}
+
+// logLevels 配置文件中的日志级别
+var logLevels = map[string]int{
+	"debug": log.LevelDebug,
+	"info":  log.LevelInfo,
+	"warn":  log.LevelWarn,
+	"error": log.LevelError,
+}
+
+// Validate 校验配置，返回由 errors.Join 合并的 *FieldError
+func (f *File) Validate() error {
+	var errs []error
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, ok := logLevels[strings.ToLower(f.LogLevel)]; f.LogLevel != "" && !ok {
+		fail("log_level", "无效的日志级别 %q，可选 debug、info、warn、error", f.LogLevel)
+	}
+	if f.Timeout < 0 {
+		fail("timeout", "不能为负数")
+	}
+	if f.Concurrency < 0 {
+		fail("concurrency", "不能为负数")
+	}
+	if f.ServerConcurrency < 0 {
+		fail("server_concurrency", "不能为负数")
+	}
+
+	seen := make(map[string]int)
+	for i, a := range f.Accounts {
+		field := fmt.Sprintf("accounts[%d]", i)
+		if a.Uid == "" {
+			fail(field+".uid", "不能为空")
+		} else if j, ok := seen[a.Uid]; ok {
+			fail(field+".uid", "与 accounts[%d] 重复", j)
+		} else {
+			seen[a.Uid] = i
+		}
+		if a.AccessKey == "" {
+			fail(field+".access_key", "不能为空")
+		}
+		if a.Server != ServerBili && a.Server != ServerChannel {
+			fail(field+".server", "无效的服务器 %q，可选 %s、%s", a.Server, ServerBili, ServerChannel)
+		}
+		if a.Proxy != "" {
+			u, err := url.Parse(a.Proxy)
+			switch {
+			case err != nil:
+				fail(field+".proxy", "%v", err)
+			case u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5":
+				fail(field+".proxy", "不支持的代理协议 %q", u.Scheme)
+			case u.Host == "":
+				fail(field+".proxy", "缺少主机")
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Level 返回日志级别（log.LevelDebug 等），未配置时为 log.LevelInfo
+func (f *File) Level() int {
+	if level, ok := logLevels[strings.ToLower(f.LogLevel)]; ok {
+		return level
+	}
+	return log.LevelInfo
+}
+
+// ServerConfig 返回服务器的客户端配置，同一服务器的账号共用，已应用文件中的超时时间和AppVer
+func (f *File) ServerConfig(server string) *Config {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if cfg, ok := f.configs[server]; ok {
+		return cfg
+	}
+
+	var cfg *Config
+	if server == ServerChannel {
+		cfg = NewChannel()
+	} else {
+		cfg = NewBili()
+	}
+	if f.Timeout > 0 {
+		cfg.SetTimeout(time.Duration(f.Timeout))
+	}
+	if f.AppVer != "" {
+		cfg.SetAppVer(f.AppVer)
+	}
+	if f.configs == nil {
+		f.configs = make(map[string]*Config)
+	}
+	f.configs[server] = cfg
+	return cfg
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeConfig 在临时目录写入配置文件，返回路径
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// fieldErrors 返回err中所有 *FieldError 的字段路径
+func fieldErrors(err error) []string {
+	var fields []string
+	switch e := err.(type) {
+	case *FieldError:
+		fields = append(fields, e.Field)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			fields = append(fields, fieldErrors(inner)...)
+		}
+	case interface{ Unwrap() error }:
+		fields = fieldErrors(e.Unwrap())
+	}
+	return fields
+}
+
+var testFiles = map[string]string{
+	"config.yaml": `
+timeout: 10s
+accounts:
+  - uid: "1"
+    access_key: k1
+  - uid: "2"
+    access_key: k2
+    server: channel
+    device:
+      seed: s2
+`,
+	"config.toml": `
+timeout = "10s"
+
+[[accounts]]
+uid = "1"
+access_key = "k1"
+
+[[accounts]]
+uid = "2"
+access_key = "k2"
+server = "channel"
+device = { seed = "s2" }
+`,
+	"config.json": `{
+  "timeout": "10s",
+  "accounts": [
+    {"uid": "1", "access_key": "k1"},
+    {"uid": "2", "access_key": "k2", "server": "channel", "device": {"seed": "s2"}}
+  ]
+}`,
+}
+
+func TestLoad(t *testing.T) {
+	for name, content := range testFiles {
+		t.Run(name, func(t *testing.T) {
+			f, err := Load(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if time.Duration(f.Timeout) != 10*time.Second {
+				t.Fatalf("timeout %v", f.Timeout)
+			}
+			if len(f.Accounts) != 2 {
+				t.Fatalf("got %d accounts", len(f.Accounts))
+			}
+			a := f.Accounts[0]
+			if a.Uid != "1" || a.AccessKey != "k1" || a.Platform != "2" || a.Channel != "1" || a.Server != ServerBili {
+				t.Fatalf("account 0 = %+v", a)
+			}
+			if b := f.Accounts[1]; b.Server != ServerChannel || b.Device.Seed != "s2" {
+				t.Fatalf("account 1 = %+v", b)
+			}
+		})
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "accounts:\n  - uid: \"1\"\n    access_key: k1\n    acess_key: typo\n",
+		"config.toml": "[[accounts]]\nuid = \"1\"\naccess_key = \"k1\"\nacess_key = \"typo\"\n",
+		"config.json": `{"accounts": [{"uid": "1", "access_key": "k1", "acess_key": "typo"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, name, content))
+			if fields := fieldErrors(err); !slices.Contains(fields, "accounts[0].acess_key") &&
+				!slices.Contains(fields, "accounts.acess_key") {
+				t.Fatalf("got %v, want unknown field accounts[0].acess_key", err)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidValue(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "concurrency: many\naccounts:\n  - uid: \"1\"\n    access_key: k1\n  - uid: \"2\"\n    access_key: [k2]\n",
+		"config.json": `{"concurrency": "many", "accounts": [{"uid": "1", "access_key": "k1"}, {"uid": "2", "access_key": ["k2"]}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, name, content))
+			fields := fieldErrors(err)
+			if !slices.Equal(fields, []string{"accounts[1].access_key", "concurrency"}) {
+				t.Fatalf("got fields %v (%v)", fields, err)
+			}
+		})
+	}
+}
+
+func TestLoadEnvOverride(t *testing.T) {
+	path := writeConfig(t, "config.yaml", testFiles["config.yaml"])
+	t.Setenv("GOPCR_TIMEOUT", "30s")
+	t.Setenv("GOPCR_ACCOUNTS_0_ACCESS_KEY", "env-key")
+	t.Setenv("GOPCR_ACCOUNTS_1_DEVICE_SEED", "env-seed")
+	// 超出长度的下标追加新账号
+	t.Setenv("GOPCR_ACCOUNTS_2_UID", "3")
+	t.Setenv("GOPCR_ACCOUNTS_2_ACCESS_KEY", "k3")
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(f.Timeout) != 30*time.Second {
+		t.Fatalf("timeout %v", f.Timeout)
+	}
+	if f.Accounts[0].AccessKey != "env-key" {
+		t.Fatalf("access_key %q", f.Accounts[0].AccessKey)
+	}
+	if f.Accounts[1].Device.Seed != "env-seed" {
+		t.Fatalf("device seed %q", f.Accounts[1].Device.Seed)
+	}
+	if len(f.Accounts) != 3 {
+		t.Fatalf("got %d accounts, want 3", len(f.Accounts))
+	}
+	if a := f.Accounts[2]; a.Uid != "3" || a.AccessKey != "k3" || a.Server != ServerBili {
+		t.Fatalf("appended account = %+v", a)
+	}
+}
+
+func TestLoadEnvInvalid(t *testing.T) {
+	path := writeConfig(t, "config.yaml", testFiles["config.yaml"])
+	t.Setenv("GOPCR_CONCURRENCY", "many")
+	_, err := Load(path)
+	if fields := fieldErrors(err); !slices.Equal(fields, []string{"concurrency"}) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f := &File{
+		LogLevel:    "verbose",
+		Concurrency: -1,
+		Accounts: []Account{
+			{Uid: "1", AccessKey: "k1", Server: ServerBili},
+			{Uid: "1", Server: "jp", Proxy: "ftp://127.0.0.1"},
+		},
+	}
+	want := []string{
+		"log_level",
+		"concurrency",
+		"accounts[1].uid",
+		"accounts[1].access_key",
+		"accounts[1].server",
+		"accounts[1].proxy",
+	}
+	if fields := fieldErrors(f.Validate()); !slices.Equal(fields, want) {
+		t.Fatalf("got %v, want %v", fields, want)
+	}
+}
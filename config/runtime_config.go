@@ -3,7 +3,7 @@ package config
 // 维护一些运行时更新的配置
 
 import (
-	"reflect"
+	"fmt"
 	"sync"
 )
 
@@ -14,19 +14,11 @@ const (
 	PcrApiHost optionType = iota // API主机
 )
 
+// OptionConfig 按配置项读写 Default() 的旧接口。
+//
+// Deprecated: 使用 *Config 的类型化方法，客户端通过 core.WithConfig 指定配置
 type OptionConfig struct {
-	mu      sync.RWMutex
-	options map[optionType]any
-}
-
-type defaultOptVal struct {
-	value any
-	typ   reflect.Type
-}
-
-var defaultOptVals = map[optionType]defaultOptVal{
-	AppVer:     {value: DefaultAppVer, typ: reflect.TypeOf(DefaultAppVer)},
-	PcrApiHost: {value: DefaultBiliApiHost, typ: reflect.TypeOf(DefaultBiliApiHost)},
+	config *Config
 }
 
 // 单例相关变量
@@ -35,37 +27,38 @@ var (
 	once     sync.Once
 )
 
-// GetInstance 获取配置的单例实例
+// GetInstance 获取配置的单例实例，读写的是 Default()
 func GetInstance() *OptionConfig {
 	once.Do(func() {
-		instance = &OptionConfig{
-			options: make(map[optionType]any),
-		}
+		instance = &OptionConfig{config: Default()}
 	})
 	return instance
 }
 
-// SetOptVal 设置单个配置项
+// SetOptVal 设置单个配置项，值的类型必须为string
 func (h *OptionConfig) SetOptVal(optType optionType, value any) error {
-	// 比较值是否相同，如果相同则直接返回
-	if h.GetOptVal(optType) == value {
-		return nil
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("配置项%d的值类型错误: %T", optType, value)
+	}
+	switch optType {
+	case AppVer:
+		h.config.SetAppVer(s)
+	case PcrApiHost:
+		h.config.SetAPIHost(s)
+	default:
+		return fmt.Errorf("未知的配置项: %d", optType)
 	}
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	h.options[optType] = value
 	return nil
 }
 
 // GetOptVal 获取配置项的值
 func (h *OptionConfig) GetOptVal(optType optionType) any {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	if val, ok := h.options[optType]; ok {
-		return val
+	switch optType {
+	case AppVer:
+		return h.config.AppVer()
+	case PcrApiHost:
+		return h.config.APIHost()
 	}
-	return defaultOptVals[optType].value
+	return nil
 }
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key 配置项，用于变更通知
+type Key int
+
+const (
+	KeyAPIHost Key = iota // API主机
+	KeyHosts              // API根地址的候选列表
+	KeyAppVer             // APP-VER
+	KeyResVer             // RES-VER
+	KeyResKey             // RES-KEY
+	KeyTimeout            // 请求超时时间
+	KeyHeaders            // 其他请求头
+)
+
+var keyNames = map[Key]string{
+	KeyAPIHost: "api_host",
+	KeyHosts:   "hosts",
+	KeyAppVer:  "app_ver",
+	KeyResVer:  "res_ver",
+	KeyResKey:  "res_key",
+	KeyTimeout: "timeout",
+	KeyHeaders: "headers",
+}
+
+func (k Key) String() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// DefaultResVer 默认资源版本
+const DefaultResVer = "10002200"
+
+// Config 客户端配置，可以被多个goroutine并发使用。
+// 多个客户端共用同一个Config时，其中一个发现的新AppVer对其他客户端同样生效
+type Config struct {
+	mu      sync.RWMutex
+	apiHost string
+	hosts   []string
+	appVer  string
+	resVer  string
+	resKey  string
+	timeout time.Duration
+	headers map[string]string // 除APP-VER、RES-VER、RES-KEY外的固定请求头
+
+	listenerMu sync.Mutex
+	listeners  map[int]func(c *Config, key Key)
+	nextId     int
+}
+
+// newConfig 创建使用默认请求头的配置
+func newConfig(apiHost, resKey string) *Config {
+	return &Config{
+		apiHost: apiHost,
+		appVer:  DefaultAppVer,
+		resVer:  DefaultResVer,
+		resKey:  resKey,
+		timeout: DefaultRequestTimeout,
+		headers: getDefaultHeaders(),
+	}
+}
+
+// NewBili 创建B服的默认配置
+func NewBili() *Config {
+	return newConfig(DefaultBiliApiHost, biliResKey)
+}
+
+// NewChannel 创建渠道服的默认配置
+func NewChannel() *Config {
+	return newConfig(DefaultChannelApiHost, channelResKey)
+}
+
+// 进程内共用的默认配置
+var (
+	defaultBili    = sync.OnceValue(NewBili)
+	defaultChannel = sync.OnceValue(NewChannel)
+)
+
+// Default 返回B服客户端默认共用的配置
+func Default() *Config {
+	return defaultBili()
+}
+
+// DefaultChannel 返回渠道服客户端默认共用的配置
+func DefaultChannel() *Config {
+	return defaultChannel()
+}
+
+// Clone 复制配置，不包括变更回调。可用于从默认配置派生相互隔离的配置
+func (c *Config) Clone() *Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &Config{
+		apiHost: c.apiHost,
+		hosts:   slices.Clone(c.hosts),
+		appVer:  c.appVer,
+		resVer:  c.resVer,
+		resKey:  c.resKey,
+		timeout: c.timeout,
+		headers: maps.Clone(c.headers),
+	}
+}
+
+// OnChange 注册变更回调，配置项的值改变后调用（设置相同的值不会触发）。
+// 回调在设置值的goroutine中同步执行。返回的函数用于取消注册
+func (c *Config) OnChange(fn func(c *Config, key Key)) (cancel func()) {
+	c.listenerMu.Lock()
+	defer c.listenerMu.Unlock()
+	if c.listeners == nil {
+		c.listeners = make(map[int]func(*Config, Key))
+	}
+	id := c.nextId
+	c.nextId++
+	c.listeners[id] = fn
+	return func() {
+		c.listenerMu.Lock()
+		defer c.listenerMu.Unlock()
+		delete(c.listeners, id)
+	}
+}
+
+// notify 调用变更回调，调用时不持有mu，回调中可以读取配置
+func (c *Config) notify(key Key) {
+	c.listenerMu.Lock()
+	listeners := slices.Collect(maps.Values(c.listeners))
+	c.listenerMu.Unlock()
+	for _, fn := range listeners {
+		fn(c, key)
+	}
+}
+
+// setString 设置字符串配置项，值改变时通知
+func (c *Config) setString(field *string, key Key, value string) {
+	c.mu.Lock()
+	if *field == value {
+		c.mu.Unlock()
+		return
+	}
+	*field = value
+	c.mu.Unlock()
+	c.notify(key)
+}
+
+// getString 读取字符串配置项
+func (c *Config) getString(field *string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return *field
+}
+
+// APIHost API主机，不带协议
+func (c *Config) APIHost() string { return c.getString(&c.apiHost) }
+
+// SetAPIHost 设置API主机
+func (c *Config) SetAPIHost(host string) { c.setString(&c.apiHost, KeyAPIHost, host) }
+
+// AppVer 游戏版本
+func (c *Config) AppVer() string { return c.getString(&c.appVer) }
+
+// SetAppVer 设置游戏版本
+func (c *Config) SetAppVer(ver string) { c.setString(&c.appVer, KeyAppVer, ver) }
+
+// ResVer 资源版本
+func (c *Config) ResVer() string { return c.getString(&c.resVer) }
+
+// SetResVer 设置资源版本
+func (c *Config) SetResVer(ver string) { c.setString(&c.resVer, KeyResVer, ver) }
+
+// ResKey 资源密钥
+func (c *Config) ResKey() string { return c.getString(&c.resKey) }
+
+// SetResKey 设置资源密钥
+func (c *Config) SetResKey(key string) { c.setString(&c.resKey, KeyResKey, key) }
+
+// Hosts API根地址的候选列表，为空时使用API主机和 source_ini/index 返回的服务器列表
+func (c *Config) Hosts() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.hosts)
+}
+
+// SetHosts 设置API根地址的候选列表
+func (c *Config) SetHosts(hosts []string) {
+	c.mu.Lock()
+	if slices.Equal(c.hosts, hosts) {
+		c.mu.Unlock()
+		return
+	}
+	c.hosts = slices.Clone(hosts)
+	c.mu.Unlock()
+	c.notify(KeyHosts)
+}
+
+// Timeout 单次请求的超时时间
+func (c *Config) Timeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.timeout
+}
+
+// SetTimeout 设置单次请求的超时时间，<=0 表示不限制
+func (c *Config) SetTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	if c.timeout == timeout {
+		c.mu.Unlock()
+		return
+	}
+	c.timeout = timeout
+	c.mu.Unlock()
+	c.notify(KeyTimeout)
+}
+
+// Headers 返回全部固定请求头，包括APP-VER、RES-VER和RES-KEY
+func (c *Config) Headers() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	headers := make(map[string]string, len(c.headers)+3)
+	maps.Copy(headers, c.headers)
+	headers["APP-VER"] = c.appVer
+	headers["RES-VER"] = c.resVer
+	headers["RES-KEY"] = c.resKey
+	return headers
+}
+
+// SetHeader 设置固定请求头，value为空时删除。
+// APP-VER、RES-VER、RES-KEY等同于对应的Set方法
+func (c *Config) SetHeader(name, value string) {
+	switch http.CanonicalHeaderKey(name) {
+	case "App-Ver":
+		c.SetAppVer(value)
+		return
+	case "Res-Ver":
+		c.SetResVer(value)
+		return
+	case "Res-Key":
+		c.SetResKey(value)
+		return
+	}
+
+	c.mu.Lock()
+	// 保留已有请求头的写法
+	for k := range c.headers {
+		if strings.EqualFold(k, name) {
+			name = k
+			break
+		}
+	}
+	if c.headers[name] == value {
+		c.mu.Unlock()
+		return
+	}
+	if value == "" {
+		delete(c.headers, name)
+	} else {
+		c.headers[name] = value
+	}
+	c.mu.Unlock()
+	c.notify(KeyHeaders)
+}
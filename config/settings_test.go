@@ -0,0 +1,91 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestConfigOnChange(t *testing.T) {
+	c := NewBili()
+	var keys []Key
+	var seen string
+	c.OnChange(func(c *Config, key Key) {
+		keys = append(keys, key)
+		// 回调执行时不持有锁，可以读取新值
+		seen = c.AppVer()
+	})
+	var cancelled int
+	cancel := c.OnChange(func(*Config, Key) {
+		cancelled++
+	})
+
+	c.SetAppVer("9.9.9")
+	if !slices.Equal(keys, []Key{KeyAppVer}) || seen != "9.9.9" {
+		t.Fatalf("keys %v, AppVer in callback %q", keys, seen)
+	}
+	if cancelled != 1 {
+		t.Fatalf("second listener called %d times, want 1", cancelled)
+	}
+
+	// 设置相同的值不通知；取消的回调不再调用
+	cancel()
+	c.SetAppVer("9.9.9")
+	c.SetHeader("app-ver", "9.9.9")
+	c.SetHeader("APP-VER", "10.0.0")
+	c.SetHosts([]string{"https://a/"})
+	c.SetHosts([]string{"https://a/"})
+	c.SetTimeout(time.Second)
+	c.SetHeader("X-Test", "1")
+	c.SetHeader("x-test", "")
+	want := []Key{KeyAppVer, KeyAppVer, KeyHosts, KeyTimeout, KeyHeaders, KeyHeaders}
+	if !slices.Equal(keys, want) {
+		t.Fatalf("keys %v, want %v", keys, want)
+	}
+	if cancelled != 1 {
+		t.Fatalf("cancelled listener called %d times", cancelled)
+	}
+	if _, ok := c.Headers()["X-Test"]; ok {
+		t.Fatal("X-Test not deleted")
+	}
+}
+
+func TestConfigClone(t *testing.T) {
+	c := NewBili()
+	c.SetHosts([]string{"https://a/", "https://b/"})
+	c.SetHeader("X-Test", "1")
+	var notified int
+	c.OnChange(func(*Config, Key) {
+		notified++
+	})
+
+	clone := c.Clone()
+	if clone.AppVer() != c.AppVer() || !slices.Equal(clone.Hosts(), c.Hosts()) || clone.Headers()["X-Test"] != "1" {
+		t.Fatalf("clone differs from the original: %v, %v", clone.Hosts(), clone.Headers())
+	}
+
+	// 修改副本不影响原配置，也不触发原配置的回调
+	clone.SetAppVer("9.9.9")
+	clone.SetHosts([]string{"https://c/"})
+	clone.SetHeader("X-Test", "2")
+	if notified != 0 {
+		t.Fatalf("original listener called %d times by the clone", notified)
+	}
+	if c.AppVer() == "9.9.9" || !slices.Equal(c.Hosts(), []string{"https://a/", "https://b/"}) || c.Headers()["X-Test"] != "1" {
+		t.Fatalf("original changed: %s, %v, %v", c.AppVer(), c.Hosts(), c.Headers())
+	}
+
+	// 修改原配置也不影响副本
+	c.SetHeader("X-Other", "1")
+	c.SetHosts(nil)
+	if _, ok := clone.Headers()["X-Other"]; ok || !slices.Equal(clone.Hosts(), []string{"https://c/"}) {
+		t.Fatalf("clone changed: %v, %v", clone.Hosts(), clone.Headers())
+	}
+
+	// 返回的列表是副本
+	hosts := clone.Hosts()
+	hosts[0] = "https://d/"
+	if clone.Hosts()[0] != "https://c/" {
+		t.Fatal("Hosts returned the internal slice")
+	}
+}
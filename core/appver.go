@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AppVersionProvider 提供最新的AppVer，check/game_start 返回204时调用
+type AppVersionProvider interface {
+	// AppVersion 返回最新的AppVer，current为服务器拒绝的版本
+	AppVersion(ctx context.Context, current string) (string, error)
+}
+
+// AppVersionCache 可选接口。实现该接口的 AppVersionProvider 在创建客户端时
+// 提供已保存的AppVer作为初始的APP-VER
+type AppVersionCache interface {
+	CachedAppVersion() string
+}
+
+// AppVersionFunc 函数形式的 AppVersionProvider
+type AppVersionFunc func(ctx context.Context, current string) (string, error)
+
+func (f AppVersionFunc) AppVersion(ctx context.Context, current string) (string, error) {
+	return f(ctx, current)
+}
+
+// StaticAppVersion 固定版本号的 AppVersionProvider，同时作为初始的APP-VER
+type StaticAppVersion string
+
+func (v StaticAppVersion) AppVersion(context.Context, string) (string, error) {
+	return string(v), nil
+}
+
+func (v StaticAppVersion) CachedAppVersion() string {
+	return string(v)
+}
+
+// DefaultAppVersionURL biligame游戏详情接口，用于获取最新AppVer
+const DefaultAppVersionURL = "https://line1-h5-pc-api.biligame.com/game/detail/content?game_base_id=102216"
+
+// BiligameAppVersion 从biligame游戏详情接口获取AppVer（默认）。
+// URL为空时使用 DefaultAppVersionURL，也可以指向返回相同格式的自定义地址
+type BiligameAppVersion struct {
+	URL    string
+	Client *http.Client // 为nil时使用5秒超时的客户端
+}
+
+func (b *BiligameAppVersion) AppVersion(ctx context.Context, _ string) (string, error) {
+	url := b.URL
+	if url == "" {
+		url = DefaultAppVersionURL
+	}
+	client := b.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get app version: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get app version: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			return
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result struct {
+		Data struct {
+			AndroidVersion string `json:"android_version"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if result.Data.AndroidVersion == "" {
+		return "", fmt.Errorf("android_version is empty")
+	}
+
+	return result.Data.AndroidVersion, nil
+}
+
+// FileAppVersion 将AppVer保存在文件中的 AppVersionProvider。
+// 文件中的版本比服务器拒绝的版本新时直接使用（例如已被其他进程更新），
+// 否则通过Provider获取并写回文件。创建客户端时使用文件中的版本作为初始APP-VER
+type FileAppVersion struct {
+	Path     string
+	Provider AppVersionProvider // 为nil时使用 BiligameAppVersion
+
+	mu sync.Mutex
+}
+
+// fileAppVersion 文件内容
+type fileAppVersion struct {
+	AppVer    string    `json:"app_ver"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (f *FileAppVersion) AppVersion(ctx context.Context, current string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ver := f.load(); ver != "" && compareAppVer(ver, current) > 0 {
+		return ver, nil
+	}
+	provider := f.Provider
+	if provider == nil {
+		provider = &BiligameAppVersion{}
+	}
+	ver, err := provider.AppVersion(ctx, current)
+	if err != nil {
+		return "", err
+	}
+	if err = f.save(ver); err != nil {
+		return "", fmt.Errorf("保存AppVer失败: %w", err)
+	}
+	return ver, nil
+}
+
+func (f *FileAppVersion) CachedAppVersion() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load()
+}
+
+// load 读取文件中的版本，文件不存在或无效时返回空
+func (f *FileAppVersion) load() string {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return ""
+	}
+	var content fileAppVersion
+	if err = json.Unmarshal(data, &content); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(content.AppVer)
+}
+
+// save 写入版本和更新时间
+func (f *FileAppVersion) save(ver string) error {
+	data, err := json.Marshal(fileAppVersion{AppVer: ver, UpdatedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.Path, data)
+}
+
+// WithAppVersionProvider 自定义获取最新AppVer的方式Option，默认使用 BiligameAppVersion。
+// provider实现 AppVersionCache 时，其保存的版本作为初始APP-VER
+func WithAppVersionProvider(provider AppVersionProvider) SessionOption {
+	return func(client *session) {
+		client.appVerProvider = provider
+	}
+}
+
+// WithAppVerURL 自定义获取最新AppVer的地址Option，
+// 等同于 WithAppVersionProvider(&BiligameAppVersion{URL: url})
+func WithAppVerURL(url string) SessionOption {
+	return WithAppVersionProvider(&BiligameAppVersion{URL: url})
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"path/filepath"
+	"testing"
+)
+
+// countingAppVersion 返回固定版本并统计调用次数
+type countingAppVersion struct {
+	ver   string
+	calls int
+}
+
+func (c *countingAppVersion) AppVersion(context.Context, string) (string, error) {
+	c.calls++
+	return c.ver, nil
+}
+
+func TestFileAppVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appver.json")
+	provider := &countingAppVersion{ver: "8.2.0"}
+	f := &FileAppVersion{Path: path, Provider: provider}
+	if ver := f.CachedAppVersion(); ver != "" {
+		t.Fatalf("CachedAppVersion %q before the file exists", ver)
+	}
+
+	ver, err := f.AppVersion(context.Background(), "8.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != "8.2.0" || provider.calls != 1 {
+		t.Fatalf("got %q after %d provider calls", ver, provider.calls)
+	}
+
+	// 新的实例从文件读取保存的版本
+	reloaded := &FileAppVersion{Path: path, Provider: provider}
+	if ver = reloaded.CachedAppVersion(); ver != "8.2.0" {
+		t.Fatalf("reloaded CachedAppVersion %q, want 8.2.0", ver)
+	}
+
+	for _, tc := range []struct {
+		current   string
+		want      string
+		fromCache bool
+	}{
+		{"8.1.0", "8.2.0", true},   // 文件中的版本较新，直接使用
+		{"8.2.0", "8.2.0", false},  // 文件中的版本也被拒绝，重新获取
+		{"8.10.0", "8.2.0", false}, // 文件中的版本比被拒绝的版本旧，不使用
+	} {
+		provider.calls = 0
+		if ver, err = reloaded.AppVersion(context.Background(), tc.current); err != nil {
+			t.Fatal(err)
+		}
+		if ver != tc.want || (provider.calls == 0) != tc.fromCache {
+			t.Fatalf("current %s: got %q after %d provider calls", tc.current, ver, provider.calls)
+		}
+	}
+}
+
+func TestFileAppVersionPersistsAcrossClients(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	srv.SetAppVer("9.9.9")
+	path := filepath.Join(t.TempDir(), "appver.json")
+	newProvider := func() *FileAppVersion {
+		return &FileAppVersion{Path: path, Provider: &BiligameAppVersion{URL: srv.AppVersionURL()}}
+	}
+
+	// 第一个客户端收到204，获取最新版本并写入文件
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()), WithAppVersionProvider(newProvider()))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Calls(mockserver.EndpointGameStart); n != 2 {
+		t.Fatalf("game_start called %d times, want 2", n)
+	}
+
+	// 第二个客户端使用文件中的版本作为初始APP-VER，不再收到204
+	cfg := config.NewBili()
+	c = newTestClient(t, srv, "u2", WithConfig(cfg), WithAppVersionProvider(newProvider()))
+	if got := cfg.AppVer(); got != "9.9.9" {
+		t.Fatalf("initial AppVer %q, want 9.9.9", got)
+	}
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Calls(mockserver.EndpointGameStart); n != 3 {
+		t.Fatalf("game_start called %d times, want 3", n)
+	}
+	if n := srv.Calls(mockserver.EndpointAppVersion); n != 1 {
+		t.Fatalf("app version fetched %d times, want 1", n)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
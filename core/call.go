@@ -0,0 +1,28 @@
+package core
+
+import (
+	"context"
+	"gopcr/models"
+)
+
+// Call 调用接口并返回响应data，Resp为响应data的类型。
+// Req通常为请求结构体的指针，例如:
+//
+//	req := models.NewHomeIndexReq()
+//	data, err := core.Call[*models.HomeIndexReq, models.HomeIndexResp](ctx, client, &req)
+func Call[Req models.IRequest, Resp any](ctx context.Context, c *Client, req Req) (*Resp, error) {
+	result, err := CallResponse[Req, Resp](ctx, c, req)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// CallResponse 与 Call 相同，但返回包含data_headers的完整响应
+func CallResponse[Req models.IRequest, Resp any](ctx context.Context, c *Client, req Req) (*models.BaseResponse[Resp], error) {
+	var result models.BaseResponse[Resp]
+	if _, err := c.callApi(ctx, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
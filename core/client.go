@@ -1,37 +1,65 @@
 package core
 
-import "gopcr/models"
+import (
+	"context"
+	"gopcr/config"
+	"gopcr/models"
+	"time"
+)
 
-// Client 封装了与游戏核心玩法相关的API调用
+// Client 封装了与游戏核心玩法相关的API调用。
+// Client 可以被多个goroutine并发使用：所有请求（包括登录）按到达顺序串行执行，
+// 以保证服务器要求的REQUEST-ID/SID请求链不被打乱
 type Client struct {
 	*session
 }
 
 // NewClient 创建一个新的 GameAPI 实例
-// 它需要一个已经初始化好的 Client
-func NewClient(sdkAccount SdkAccount, options ...SessionOption) (*Client, error) {
-	s, err := newSession(sdkAccount, options...)
+// ctx 用于创建过程中获取配置的请求，取消后创建失败。
+// 服务器维护中时返回 *models.MaintenanceError，可用 errors.As 获取维护信息
+func NewClient(ctx context.Context, sdkAccount SdkAccount, options ...SessionOption) (*Client, error) {
+	s, err := newSession(ctx, sdkAccount, options...)
 	if err != nil {
 		return nil, err
 	}
 	return &Client{session: s}, nil
 }
 
-func (c *Client) HomeIndex() (*models.BaseResponse[models.HomeIndexResp], error) {
+func (c *Client) HomeIndex(ctx context.Context) (*models.BaseResponse[models.HomeIndexResp], error) {
 	homeIndexReq := models.NewHomeIndexReq()
 	homeIndexReq.MessageId = 1
 	homeIndexReq.GoldHistory = 0
 	homeIndexReq.IsFirst = 1
 	homeIndexReq.TipsIdList = []int{}
-	var homeIndexResult models.BaseResponse[models.HomeIndexResp]
+	return CallResponse[*models.HomeIndexReq, models.HomeIndexResp](ctx, c, &homeIndexReq)
+}
 
-	_, err := c.callApi(&homeIndexReq, &homeIndexResult)
-	if err != nil {
+// CallRaw 调用未建模的接口，body为请求体（viewer_id自动填写）。
+// 与其他接口一样经过登录、加密和请求链处理，返回解码后的完整响应，
+// 包含 "data_headers" 和 "data" 两个键，可转换为 models.RawResponse 读取
+func (c *Client) CallRaw(ctx context.Context, path string, body map[string]any) (map[string]any, error) {
+	var result models.RawResponse
+	if _, err := c.callApi(ctx, models.NewRawRequest(path, body), &result); err != nil {
 		return nil, err
 	}
-	return &homeIndexResult, nil
+	return result, nil
+}
+
+// SessionExpiresAt 返回会话的过期时间，即load/index返回的每日重置时间。
+// 过期后下一次调用会自动重新登录；尚未登录时返回零值
+func (c *Client) SessionExpiresAt() time.Time {
+	expireTime := c.expireTime.Load()
+	if expireTime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(expireTime), 0)
 }
 
 func (c *Client) Close() {
 	c.session.Close()
 }
+
+// Config 返回客户端使用的配置
+func (c *Client) Config() *config.Config {
+	return c.config
+}
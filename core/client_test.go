@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"gopcr/models"
+	"sync"
+	"testing"
+)
+
+// TestClientConcurrentCalls 并发调用同一个客户端时只登录一次，且请求链不被打乱。
+// 使用 go test -race 运行以检查数据竞争
+func TestClientConcurrentCalls(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	c := newTestClient(t, srv, "u1")
+
+	const workers, calls = 8, 5
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*calls)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range calls {
+				if _, err := c.HomeIndex(context.Background()); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if errs := srv.Errors(); len(errs) != 0 {
+		t.Fatalf("mock server reported errors: %v", errs)
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 1 {
+		t.Fatalf("sdk_login called %d times, want 1", n)
+	}
+}
+
+func TestCallRaw(t *testing.T) {
+	srv := mockserver.New(mockserver.WithManifestVer("10010"))
+	defer srv.Close()
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()))
+	ctx := context.Background()
+
+	// 已注册的加密接口，路径开头的/被忽略
+	resp, err := c.CallRaw(ctx, "/"+mockserver.EndpointHomeIndex, map[string]any{
+		"message_id": 1, "gold_history": 0, "is_first": 1, "tips_id_list": []int{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := models.RawResponse(resp)
+	if raw.GetResultCode() != models.ResultCodeSuccess || raw.GetRequestId() == "" {
+		t.Fatalf("data_headers %v", raw.DataHeaders())
+	}
+	// 数字的具体类型取决于解码方式，按字符串比较
+	if got, want := fmt.Sprint(raw.Data()["daily_reset_time"]), fmt.Sprint(c.SessionExpiresAt().Unix()); got != want {
+		t.Fatalf("daily_reset_time %s, want %s", got, want)
+	}
+
+	// 不加密的接口
+	resp, err = c.CallRaw(ctx, mockserver.EndpointMaintenanceStatus, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := models.RawResponse(resp).Data()["manifest_ver"]; got != "10010" {
+		t.Fatalf("manifest_ver %v, want 10010", got)
+	}
+
+	// 非成功的结果码与其他接口一样返回 *models.ApiError
+	srv.ScriptResultCodes(mockserver.EndpointHomeIndex, models.ResultCodeServerBusy)
+	var apiErr *models.ApiError
+	if _, err = c.CallRaw(ctx, mockserver.EndpointHomeIndex, nil); !errors.As(err, &apiErr) || apiErr.ApiCode != models.ResultCodeServerBusy {
+		t.Fatalf("got %v, want result code %d", err, models.ResultCodeServerBusy)
+	}
+	if _, err = c.CallRaw(ctx, "unknown/endpoint", nil); ClassifyError(err) != FailureHTTPStatus {
+		t.Fatalf("got %v, want an HTTP status error", err)
+	}
+
+	// 原始请求同样推进请求链
+	if _, err = c.HomeIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gopcr/config"
+	"math/rand/v2"
+	"os"
+)
+
+// DeviceProfile 账号使用的设备信息。默认所有账号使用相同的设备信息，
+// 为每个账号指定不同的设备可以避免账号之间被关联
+type DeviceProfile struct {
+	DeviceId     string `json:"device_id"`     // DEVICE-ID，32位十六进制
+	Model        string `json:"model"`         // DEVICE-NAME，例如 "Xiaomi 2211133C"
+	OSVersion    string `json:"os_version"`    // PLATFORM-OS-VERSION
+	GPU          string `json:"gpu"`           // GRAPHICS-DEVICE-NAME
+	Carrier      string `json:"carrier"`       // load/index 请求体中的运营商
+	UnityVersion string `json:"unity_version"` // X-Unity-Version，同时用于User-Agent，需与游戏客户端一致
+}
+
+// deviceModel 生成设备时使用的机型，GPU和系统版本与机型匹配
+type deviceModel struct {
+	name      string
+	gpu       string
+	android   []int  // 可能的Android版本
+	romPrefix string // 系统版本号中的ROM前缀
+}
+
+var deviceModels = []deviceModel{
+	{"Xiaomi 2211133C", "Adreno (TM) 740", []int{13, 14}, "V"},
+	{"Xiaomi 23127PN0CC", "Adreno (TM) 750", []int{14}, "V"},
+	{"Redmi 23013RK75C", "Adreno (TM) 740", []int{13, 14}, "V"},
+	{"Redmi 22081212C", "Adreno (TM) 730", []int{12, 13, 14}, "V"},
+	{"OnePlus PJD110", "Adreno (TM) 750", []int{14}, "PJD110_"},
+	{"OPPO PHZ110", "Adreno (TM) 740", []int{13, 14}, "PHZ110_"},
+	{"vivo V2309A", "Immortalis-G720", []int{14}, "PD2309_A_"},
+	{"vivo V2229A", "Adreno (TM) 740", []int{13, 14}, "PD2229_A_"},
+	{"samsung SM-S9180", "Adreno (TM) 740", []int{13, 14}, "S9180ZC"},
+	{"HONOR PGT-AN10", "Adreno (TM) 740", []int{13, 14}, "PGT-AN10 "},
+}
+
+// androidBuilds Android版本对应的API级别和构建号
+var androidBuilds = map[int]struct {
+	api   int
+	build string
+}{
+	12: {31, "SKQ1.211006.001"},
+	13: {33, "TKQ1.221114.001"},
+	14: {34, "UKQ1.230804.001"},
+}
+
+var carriers = []string{"中国移动", "中国联通", "中国电信"}
+
+// NewDeviceProfile 由seed生成一个设备，相同的seed总是得到相同的设备。
+// 通常以账号的uid作为seed。生成规则可能随版本变化，需要长期固定时用 LoadOrCreateDeviceProfile 保存
+func NewDeviceProfile(seed string) DeviceProfile {
+	sum := sha256.Sum256([]byte("gopcr-device:" + seed))
+	r := rand.New(rand.NewPCG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16])))
+
+	model := deviceModels[r.IntN(len(deviceModels))]
+	android := model.android[r.IntN(len(model.android))]
+	build := androidBuilds[android]
+
+	deviceId := make([]byte, 16)
+	for i := range deviceId {
+		deviceId[i] = byte(r.Uint32())
+	}
+	return DeviceProfile{
+		DeviceId: hex.EncodeToString(deviceId),
+		Model:    model.name,
+		OSVersion: fmt.Sprintf("Android OS %d / API-%d (%s/%s%d.0.%d.%d)",
+			android, build.api, build.build, model.romPrefix, android, r.IntN(30), r.IntN(10)),
+		GPU:          model.gpu,
+		Carrier:      carriers[r.IntN(len(carriers))],
+		UnityVersion: config.DefaultUnityVersion,
+	}
+}
+
+// Headers 返回设备信息对应的请求头，不包括为空的字段
+func (p DeviceProfile) Headers() map[string]string {
+	headers := make(map[string]string)
+	for name, value := range map[string]string{
+		"DEVICE-ID":            p.DeviceId,
+		"DEVICE-NAME":          p.Model,
+		"PLATFORM-OS-VERSION":  p.OSVersion,
+		"GRAPHICS-DEVICE-NAME": p.GPU,
+		"X-Unity-Version":      p.UnityVersion,
+	} {
+		if value != "" {
+			headers[name] = value
+		}
+	}
+	if p.UnityVersion != "" {
+		headers["User-Agent"] = config.UnityUserAgent(p.UnityVersion)
+	}
+	return headers
+}
+
+// Save 将设备信息以JSON格式保存到path
+func (p DeviceProfile) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// LoadDeviceProfile 读取 DeviceProfile.Save 保存的设备信息
+func LoadDeviceProfile(path string) (DeviceProfile, error) {
+	var p DeviceProfile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, err
+	}
+	if err = json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("解析设备信息 %s 失败: %w", path, err)
+	}
+	return p, nil
+}
+
+// LoadOrCreateDeviceProfile 读取path中保存的设备信息，文件不存在时由seed生成并保存
+func LoadOrCreateDeviceProfile(path, seed string) (DeviceProfile, error) {
+	p, err := LoadDeviceProfile(path)
+	if err == nil || !os.IsNotExist(err) {
+		return p, err
+	}
+	p = NewDeviceProfile(seed)
+	if err = p.Save(path); err != nil {
+		return p, fmt.Errorf("保存设备信息失败: %w", err)
+	}
+	return p, nil
+}
+
+// WithDeviceProfile 使用指定设备Option：设置设备相关的请求头，以及 load/index 请求体中的运营商
+func WithDeviceProfile(profile DeviceProfile) SessionOption {
+	return func(client *session) {
+		client.device = &profile
+		client.httpClient.SetHeaders(profile.Headers())
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"gopcr/config"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewDeviceProfileDeterministic(t *testing.T) {
+	a, b := NewDeviceProfile("seed-1"), NewDeviceProfile("seed-1")
+	if a != b {
+		t.Fatalf("same seed gave different profiles:\n%+v\n%+v", a, b)
+	}
+	if len(a.DeviceId) != 32 || a.Model == "" || a.OSVersion == "" || a.GPU == "" || a.Carrier == "" {
+		t.Fatalf("incomplete profile %+v", a)
+	}
+	if c := NewDeviceProfile("seed-2"); c.DeviceId == a.DeviceId {
+		t.Fatalf("different seeds gave the same device id %s", a.DeviceId)
+	}
+}
+
+func TestFileDevice(t *testing.T) {
+	// 未配置设备时使用默认设备
+	if p, err := fileDevice(config.Account{Uid: "1"}); err != nil || p != nil {
+		t.Fatalf("got %+v, %v, want nil", p, err)
+	}
+
+	// 只指定字段时不生成设备
+	p, err := fileDevice(config.Account{Uid: "1", Device: config.Device{Model: "Pixel 8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *p != (DeviceProfile{Model: "Pixel 8"}) {
+		t.Fatalf("got %+v", *p)
+	}
+
+	p, err = fileDevice(config.Account{Uid: "1", Device: config.Device{Seed: "s", Model: "Pixel 8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewDeviceProfile("s")
+	want.Model = "Pixel 8"
+	if *p != want {
+		t.Fatalf("got %+v, want %+v", *p, want)
+	}
+
+	// 指定文件时以uid为seed生成并保存，之后读取保存的设备
+	path := filepath.Join(t.TempDir(), "device.json")
+	p, err = fileDevice(config.Account{Uid: "1", Device: config.Device{File: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *p != NewDeviceProfile("1") {
+		t.Fatalf("got %+v", *p)
+	}
+	saved, err := LoadDeviceProfile(path)
+	if err != nil || saved != *p {
+		t.Fatalf("saved %+v, %v", saved, err)
+	}
+}
@@ -0,0 +1,13 @@
+// Code generated by gopcr-gen. DO NOT EDIT.
+
+package core
+
+import (
+	"context"
+	"gopcr/models"
+)
+
+// ProfileGetProfile 调用 profile/get_profile，查询玩家信息
+func (c *Client) ProfileGetProfile(ctx context.Context, req *models.ProfileGetProfileReq) (*models.BaseResponse[models.ProfileGetProfileResp], error) {
+	return CallResponse[*models.ProfileGetProfileReq, models.ProfileGetProfileResp](ctx, c, req)
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"gopcr/config"
+	"gopcr/log"
+)
+
+// WithProxy 通过代理发送请求Option，支持http、https和socks5
+func WithProxy(proxyURL string) SessionOption {
+	return func(client *session) {
+		client.httpClient.SetProxy(proxyURL)
+	}
+}
+
+// WithHeaders 覆盖请求头Option，优先于配置中的固定请求头，例如账号使用的设备信息
+func WithHeaders(headers map[string]string) SessionOption {
+	return func(client *session) {
+		client.httpClient.SetHeaders(headers)
+	}
+}
+
+// FileAccount 将配置文件中的账号转换为 SdkAccount 及其 SessionOption。
+// 同一服务器的账号共用 f.ServerConfig 返回的配置。读取或保存设备文件失败时返回错误
+func FileAccount(f *config.File, account config.Account) (SdkAccount, []SessionOption, error) {
+	sdkAccount := SdkAccount{
+		Uid:       account.Uid,
+		AccessKey: account.AccessKey,
+		Platform:  account.Platform,
+		Channel:   account.Channel,
+	}
+	options := []SessionOption{WithConfig(f.ServerConfig(account.Server))}
+	if account.Server == config.ServerChannel {
+		options = append(options, WithChannelServer())
+	}
+	if account.Proxy != "" {
+		options = append(options, WithProxy(account.Proxy))
+	}
+	device, err := fileDevice(account)
+	if err != nil {
+		return sdkAccount, nil, fmt.Errorf("账号 %s: %w", account.Uid, err)
+	}
+	if device != nil {
+		options = append(options, WithDeviceProfile(*device))
+	}
+	return sdkAccount, options, nil
+}
+
+// fileDevice 生成或读取账号的设备，并应用配置中指定的字段。
+// 未配置设备时返回nil，使用默认设备
+func fileDevice(account config.Account) (*DeviceProfile, error) {
+	d := account.Device
+	var profile DeviceProfile
+	switch {
+	case d.File != "":
+		var err error
+		if profile, err = LoadOrCreateDeviceProfile(d.File, cmp.Or(d.Seed, account.Uid)); err != nil {
+			return nil, err
+		}
+	case d.Seed != "":
+		profile = NewDeviceProfile(d.Seed)
+	}
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&profile.DeviceId, d.Id},
+		{&profile.Model, d.Model},
+		{&profile.OSVersion, d.OSVersion},
+		{&profile.GPU, d.GPU},
+		{&profile.Carrier, d.Carrier},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	if profile == (DeviceProfile{}) {
+		return nil, nil
+	}
+	return &profile, nil
+}
+
+// NewClientsFromFile 为配置文件中的每个账号依次创建客户端，顺序与 f.Accounts 一致，
+// 并按配置设置全局日志级别。options追加在每个账号的选项之后。
+// 任一账号创建失败时关闭已创建的客户端并返回错误
+func NewClientsFromFile(ctx context.Context, f *config.File, options ...SessionOption) ([]*Client, error) {
+	if f.LogLevel != "" {
+		log.SetLevel(f.Level())
+	}
+	clients := make([]*Client, 0, len(f.Accounts))
+	closeAll := func() {
+		for _, c := range clients {
+			c.Close()
+		}
+	}
+	for _, account := range f.Accounts {
+		sdkAccount, accountOptions, err := FileAccount(f, account)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		client, err := NewClient(ctx, sdkAccount, append(accountOptions, options...)...)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("创建账号 %s 的客户端失败: %w", account.Uid, err)
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostCheckTimeout 健康检查单个候选地址的超时时间
+const hostCheckTimeout = 3 * time.Second
+
+// hostList API根地址的候选列表。
+// 当前地址连接失败或返回5xx时切换到下一个候选
+type hostList struct {
+	mu      sync.Mutex
+	hosts   []string
+	current int
+	fixed   bool // 由 WithHosts 指定，不使用 source_ini/index 返回的列表
+}
+
+// normalizeHost 将服务器列表中的条目统一为API根地址。
+// 条目可以是不带协议的主机（默认https），也可以是完整的URL
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return ""
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	// 与 resty.Client.SetBaseURL 保持一致
+	return strings.TrimRight(host, "/")
+}
+
+// set 替换候选列表，忽略空条目和重复条目
+func (l *hostList) set(hosts []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.hosts = l.hosts[:0]
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		host = normalizeHost(host)
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		l.hosts = append(l.hosts, host)
+	}
+	l.current = 0
+}
+
+// len 返回候选数量
+func (l *hostList) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.hosts)
+}
+
+// get 返回当前使用的地址
+func (l *hostList) get() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.hosts) == 0 {
+		return ""
+	}
+	return l.hosts[l.current]
+}
+
+// all 返回从当前地址开始的所有候选
+func (l *hostList) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hosts := make([]string, 0, len(l.hosts))
+	for i := range l.hosts {
+		hosts = append(hosts, l.hosts[(l.current+i)%len(l.hosts)])
+	}
+	return hosts
+}
+
+// next 切换到下一个候选并返回
+func (l *hostList) next() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.hosts) == 0 {
+		return ""
+	}
+	l.current = (l.current + 1) % len(l.hosts)
+	return l.hosts[l.current]
+}
+
+// use 切换到指定的候选
+func (l *hostList) use(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, h := range l.hosts {
+		if h == host {
+			l.current = i
+			return
+		}
+	}
+}
+
+// WithHosts 指定API根地址的候选列表Option，按顺序使用并在失败时切换。
+// 指定后不再使用 source_ini/index 返回的服务器列表
+func WithHosts(hosts ...string) SessionOption {
+	return func(client *session) {
+		client.hosts.set(hosts)
+		client.hosts.fixed = true
+		if host := client.hosts.get(); host != "" {
+			client.httpClient.SetBaseURL(host)
+		}
+	}
+}
+
+// useServerList 使用 source_ini/index 返回的服务器列表作为候选，
+// 当前地址作为最后的候选保留。随后选择最先响应的健康地址
+func (s *session) useServerList(ctx context.Context, servers []string) {
+	if s.hosts.fixed {
+		s.selectHost(ctx)
+		return
+	}
+	if len(servers) == 0 {
+		return
+	}
+	candidates := append(append([]string(nil), servers...), s.httpClient.BaseURL)
+	s.hosts.set(candidates)
+	s.selectHost(ctx)
+}
+
+// selectHost 同时检查所有候选地址，使用最先响应的健康地址，其余检查随即取消。
+// 不可用的地址最多使选择等待一次 hostCheckTimeout。
+// 全部不可用时保持当前地址，由之后的请求返回错误
+func (s *session) selectHost(ctx context.Context) {
+	hosts := s.hosts.all()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		host string
+		err  error
+	}
+	results := make(chan result, len(hosts))
+	for _, host := range hosts {
+		go func() {
+			results <- result{host, s.checkHost(ctx, host)}
+		}()
+	}
+	for range hosts {
+		r := <-results
+		if r.err != nil {
+			s.logger.Debug("API地址不可用", "host", r.host, "error", r.err)
+			continue
+		}
+		s.hosts.use(r.host)
+		s.httpClient.SetBaseURL(r.host)
+		s.logger.Debug("使用API地址", "host", r.host)
+		return
+	}
+	if host := s.hosts.get(); host != "" {
+		s.httpClient.SetBaseURL(host)
+	}
+}
+
+// checkHost 健康检查：能建立连接且状态码不是5xx即视为可用
+func (s *session) checkHost(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, hostCheckTimeout)
+	defer cancel()
+
+	resp, err := s.httpClient.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		Get(host + "/")
+	if err != nil {
+		return err
+	}
+	_ = resp.RawBody().Close()
+	if resp.StatusCode() >= http.StatusInternalServerError {
+		return fmt.Errorf("HTTP状态码: %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// failover 当前地址不可用，切换到下一个候选
+func (s *session) failover() string {
+	host := s.hosts.next()
+	s.httpClient.SetBaseURL(host)
+	return host
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// frontend 转发到模拟服务器的地址。broken为true时API请求返回503，
+// 健康检查（GET /）延迟checkDelay后响应
+func frontend(t *testing.T, srv *mockserver.Server, checkDelay time.Duration) (*httptest.Server, *atomic.Bool) {
+	t.Helper()
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	var broken atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			time.Sleep(checkDelay)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if broken.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &broken
+}
+
+func TestSelectHostSkipsHangingHost(t *testing.T) {
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	srv := mockserver.New(mockserver.WithServerList(hanging.URL))
+	defer srv.Close()
+
+	// 服务器列表中的地址不响应，当前地址作为候选被选中，且不必等待检查超时
+	start := time.Now()
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()))
+	if elapsed := time.Since(start); elapsed >= hostCheckTimeout {
+		t.Fatalf("NewClient took %v", elapsed)
+	}
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if host := c.ExportState().Host; host != normalizeHost(srv.URL) {
+		t.Fatalf("using %s, want %s", host, srv.URL)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	primary, broken := frontend(t, srv, 0)
+	secondary, _ := frontend(t, srv, 200*time.Millisecond)
+
+	acc := SdkAccount{Uid: "u1", AccessKey: "key", Platform: "2", Channel: "1"}
+	c, err := NewClient(context.Background(), acc,
+		WithHosts(primary.URL, secondary.URL), WithConfig(config.NewBili()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if host := c.ExportState().Host; host != primary.URL {
+		t.Fatalf("using %s, want the faster host %s", host, primary.URL)
+	}
+
+	// 当前地址返回503，切换到下一个候选并继续请求链
+	broken.Store(true)
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if host := c.ExportState().Host; host != secondary.URL {
+		t.Fatalf("using %s after failover, want %s", host, secondary.URL)
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 1 {
+		t.Fatalf("sdk_login called %d times, want 1", n)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gopcr/captcha"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"gopcr/models"
+	"net/http"
+	"testing"
+)
+
+// newTestClient 创建连接到模拟服务器的客户端，测试结束时关闭
+func newTestClient(t *testing.T, srv *mockserver.Server, uid string, options ...SessionOption) *Client {
+	t.Helper()
+	options = append([]SessionOption{WithBaseURL(srv.URL)}, options...)
+	c, err := NewClient(context.Background(), SdkAccount{Uid: uid, AccessKey: "key", Platform: "2", Channel: "1"}, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestLoginStopsOnUnrecoverableErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		account  mockserver.Account
+		want     error
+		endpoint string
+	}{
+		{"risk control without solver", mockserver.Account{Uid: "risky", Risky: true}, models.ErrRiskControl, mockserver.EndpointSdkLogin},
+		{"tutorial not finished", mockserver.Account{Uid: "newbie", TutorialUnfinished: true}, models.ErrTutorialNotFinished, mockserver.EndpointGameStart},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := mockserver.New()
+			defer srv.Close()
+			srv.AddAccount(tc.account)
+			c := newTestClient(t, srv, tc.account.Uid)
+
+			_, err := c.HomeIndex(context.Background())
+			if !errors.Is(err, tc.want) || !errors.Is(err, models.ErrLoginFailed) {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+			if n := srv.Calls(tc.endpoint); n != 1 {
+				t.Fatalf("%s called %d times, want 1", tc.endpoint, n)
+			}
+		})
+	}
+}
+
+// mockFetcher 从模拟服务器获取极验挑战
+func mockFetcher(srv *mockserver.Server) captcha.Fetcher {
+	return captcha.FetcherFunc(func(ctx context.Context) (*captcha.Challenge, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.CaptchaURL(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		var challenge captcha.Challenge
+		if err = json.NewDecoder(resp.Body).Decode(&challenge); err != nil {
+			return nil, err
+		}
+		return &challenge, nil
+	})
+}
+
+func TestLoginWithCaptchaSolver(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	srv.AddAccount(mockserver.Account{Uid: "risky", Risky: true})
+
+	var solved int
+	solver := captcha.SolverFunc(func(ctx context.Context, challenge *captcha.Challenge) (*captcha.Solution, error) {
+		solved++
+		return captcha.StaticSolver(mockserver.SolveChallenge).Solve(ctx, challenge)
+	})
+	c := newTestClient(t, srv, "risky", WithConfig(config.NewBili()), WithCaptchaSolver(mockFetcher(srv), solver))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if solved != 1 {
+		t.Fatalf("solver called %d times, want 1", solved)
+	}
+	if n := srv.Calls(mockserver.EndpointCaptcha); n != 1 {
+		t.Fatalf("captcha fetched %d times, want 1", n)
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 2 {
+		t.Fatalf("sdk_login called %d times, want 2", n)
+	}
+
+	// 结果错误时仍然触发风控，登录失败
+	wrong := captcha.StaticSolver(func(string) string { return "wrong" })
+	c = newTestClient(t, srv, "risky", WithConfig(config.NewBili()), WithCaptchaSolver(mockFetcher(srv), wrong))
+	if _, err := c.HomeIndex(context.Background()); !errors.Is(err, models.ErrRiskControl) {
+		t.Fatalf("got %v, want ErrRiskControl", err)
+	}
+}
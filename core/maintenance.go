@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"gopcr/models"
+	"time"
+)
+
+// MaintenancePolicy WaitForService 的轮询间隔，退避方式与 RetryPolicy 相同
+type MaintenancePolicy struct {
+	BaseDelay  time.Duration // 第一次重新检查前的等待时间
+	MaxDelay   time.Duration // 等待时间上限，0表示不限制
+	Multiplier float64       // 每次等待时间的倍数，<=1 时取2
+	Jitter     float64       // 随机抖动比例，取值0~1
+}
+
+// defaultMaintenancePolicy WaitForService 默认的轮询间隔：30s起指数退避，上限5分钟
+var defaultMaintenancePolicy = MaintenancePolicy{
+	BaseDelay:  30 * time.Second,
+	MaxDelay:   5 * time.Minute,
+	Multiplier: 2,
+	Jitter:     0.1,
+}
+
+// WithMaintenancePolicy 自定义 WaitForService 的轮询间隔Option
+func WithMaintenancePolicy(policy MaintenancePolicy) SessionOption {
+	return func(client *session) {
+		client.maintenancePolicy = policy
+	}
+}
+
+// backoff 计算第n次检查后的等待时间
+func (p MaintenancePolicy) backoff(n int) time.Duration {
+	return RetryPolicy{
+		BaseDelay:  p.BaseDelay,
+		MaxDelay:   p.MaxDelay,
+		Multiplier: p.Multiplier,
+		Jitter:     p.Jitter,
+	}.backoff(n)
+}
+
+// maintenanceStatus 查询维护状态。维护中时返回状态和 *models.MaintenanceError
+func (s *session) maintenanceStatus(ctx context.Context) (*models.SourceIniGetMaintenanceStatusResp, error) {
+	maintenanceReq := models.NewSourceIniGetMaintenanceStatusReq()
+	var maintenanceResult models.BaseResponse[models.SourceIniGetMaintenanceStatusResp]
+
+	_, err := s.execWithRetry(ctx, &maintenanceReq, &maintenanceResult)
+	if errors.Is(err, models.ErrMaintenance) {
+		return &maintenanceResult.Data, maintenanceResult.Data.MaintenanceError(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &maintenanceResult.Data, nil
+}
+
+// toMaintenanceError 将结果码101的错误补充为带维护信息的 *models.MaintenanceError，
+// 其他错误原样返回
+func (s *session) toMaintenanceError(ctx context.Context, err error) error {
+	if !errors.Is(err, models.ErrMaintenance) {
+		return err
+	}
+	var maintenanceErr *models.MaintenanceError
+	if errors.As(err, &maintenanceErr) {
+		return err
+	}
+	status, statusErr := s.maintenanceStatus(ctx)
+	if status == nil {
+		// 无法获取维护信息，仍返回类型化的错误
+		s.logger.Error("获取维护状态失败", "error", statusErr)
+		return &models.MaintenanceError{Err: err}
+	}
+	return status.MaintenanceError(err)
+}
+
+// WaitForService 轮询维护状态直到服务器开放，维护期间按 WithMaintenancePolicy 的间隔退避；
+// 已知维护结束时间时在结束时间附近重新检查。网络错误和5xx视为暂时不可用继续等待，
+// 其他错误或ctx取消时返回
+func (c *Client) WaitForService(ctx context.Context) error {
+	ctx, cancel := c.requestCtx(ctx)
+	defer cancel()
+
+	policy := c.maintenancePolicy
+	for n := 1; ; n++ {
+		err := c.checkService(ctx)
+		if err == nil {
+			return nil
+		}
+		kind := ClassifyError(err)
+		if kind != FailureMaintenance && kind != FailureNetwork && kind != FailureHTTP5xx {
+			return err
+		}
+
+		delay := policy.backoff(n)
+		var maintenanceErr *models.MaintenanceError
+		if errors.As(err, &maintenanceErr) && !maintenanceErr.EndTime.IsZero() {
+			if untilEnd := time.Until(maintenanceErr.EndTime); untilEnd > policy.BaseDelay &&
+				(policy.MaxDelay <= 0 || untilEnd < policy.MaxDelay) {
+				delay = untilEnd
+			}
+		}
+		c.logger.Debug("服务器暂不可用，等待重新检查", "kind", kind.String(), "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// checkService 查询一次维护状态，开放时更新MANIFEST-VER
+func (s *session) checkService(ctx context.Context) error {
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.release()
+
+	status, err := s.maintenanceStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if status.ManifestVer != "" {
+		s.httpClient.SetHeader("MANIFEST-VER", status.ManifestVer)
+	}
+	return nil
+}
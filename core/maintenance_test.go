@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"testing"
+	"time"
+)
+
+func TestWaitForServiceUntilWindowEnds(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	policy := MaintenancePolicy{BaseDelay: 20 * time.Millisecond, MaxDelay: 5 * time.Second}
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()), WithMaintenancePolicy(policy))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 维护结束时间以秒为单位下发，取整秒；服务器在结束时间前开放
+	end := time.Now().Add(1500 * time.Millisecond).Truncate(time.Second)
+	srv.SetMaintenanceWindow("维护中", time.Now(), end)
+	timer := time.AfterFunc(time.Until(end)-100*time.Millisecond, srv.ClearMaintenance)
+	defer timer.Stop()
+
+	if err := c.WaitForService(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Now().Before(end.Add(-100 * time.Millisecond)) {
+		t.Fatal("WaitForService returned before the maintenance ended")
+	}
+	// 已知结束时间时直接等到结束时间，而不是按BaseDelay轮询
+	if n := srv.Calls(mockserver.EndpointMaintenanceStatus); n > 4 {
+		t.Fatalf("maintenance status polled %d times", n)
+	}
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForServiceCancel(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	policy := MaintenancePolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()), WithMaintenancePolicy(policy))
+
+	// 没有结束时间的维护，按退避间隔轮询直到ctx超时
+	srv.SetMaintenance("维护中")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := c.WaitForService(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if n := srv.Calls(mockserver.EndpointMaintenanceStatus); n < 3 {
+		t.Fatalf("maintenance status polled %d times, want at least 3", n)
+	}
+}
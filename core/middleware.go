@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"github.com/go-resty/resty/v2"
+	"gopcr/models"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Exchange 一次请求/响应交换，在中间件之间传递。
+// 重试、切换地址时每次尝试都会经过中间件，各自使用新的Exchange
+type Exchange struct {
+	Request  models.IRequest  // 请求，加密前的明文
+	Response models.IResponse // 解码目标，next返回后为解码后的响应
+	// Header 本次请求的请求头，初始为客户端当前的请求头（包括REQUEST-ID/SID），
+	// 调用next之前可以修改或添加
+	Header http.Header
+	Host   string    // 本次请求使用的API根地址
+	Start  time.Time // 进入中间件链的时间
+	// Logger 带有account、viewer_id和endpoint属性的日志，已脱敏
+	Logger *slog.Logger
+
+	StatusCode     int           // HTTP状态码，请求未发出时为0
+	ResponseHeader http.Header   // 响应头，请求未发出时为nil
+	Duration       time.Duration // 发送请求到完成解密的耗时
+
+	resp *resty.Response
+}
+
+// Handler 处理一次请求交换，成功时ex.Response已被填充。
+// 返回的错误会按照 ClassifyError 分类，自定义错误归为 FailureOther
+type Handler func(ctx context.Context, ex *Exchange) error
+
+// Middleware 包装Handler，可以在调用next前后观察或修改请求和响应，
+// 也可以不调用next，直接填充ex.Response（缓存）或返回错误（故障注入）。
+// 结果码检查、AppVer更新和请求链推进在中间件链之后进行，对直接填充的响应同样生效
+type Middleware func(next Handler) Handler
+
+// WithMiddleware 添加请求中间件Option，包裹从准备请求到响应解密的过程。
+// 多次调用时追加，先添加的位于外层
+func WithMiddleware(middlewares ...Middleware) SessionOption {
+	return func(client *session) {
+		client.middlewares = append(client.middlewares, middlewares...)
+	}
+}
+
+// buildHandler 组合中间件与实际发送请求的Handler
+func (s *session) buildHandler() Handler {
+	handler := s.roundTrip
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	return handler
+}
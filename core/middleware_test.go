@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// isHomeIndex 是否为 home/index 请求，测试中间件只处理该接口，其他请求直接交给next
+func isHomeIndex(ex *Exchange) bool {
+	u, err := ex.Request.GetUrl()
+	return err == nil && strings.HasSuffix(u.Path, mockserver.EndpointHomeIndex)
+}
+
+// recordingMiddleware 在调用next前后记录name
+func recordingMiddleware(name string, events *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, ex *Exchange) error {
+			if !isHomeIndex(ex) {
+				return next(ctx, ex)
+			}
+			*events = append(*events, name+":before")
+			err := next(ctx, ex)
+			*events = append(*events, name+":after")
+			return err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	var events []string
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()),
+		WithMiddleware(recordingMiddleware("a", &events)),
+		WithMiddleware(recordingMiddleware("b", &events)))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 登录流程中的home/index和本次调用各经过一次中间件链，先添加的位于外层
+	want := []string{"a:before", "b:before", "b:after", "a:after"}
+	if !slices.Equal(events, slices.Concat(want, want)) {
+		t.Fatalf("got %v, want %v twice", events, want)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	errInjected := errors.New("injected")
+	var (
+		events []string
+		mode   string // 为空时正常发送请求
+	)
+	shortCircuit := func(next Handler) Handler {
+		return func(ctx context.Context, ex *Exchange) error {
+			if !isHomeIndex(ex) {
+				return next(ctx, ex)
+			}
+			switch mode {
+			case "cache":
+				return json.Unmarshal([]byte(`{"data_headers":{"result_code":1},"data":{"daily_reset_time":42}}`), ex.Response)
+			case "fail":
+				return errInjected
+			}
+			return next(ctx, ex)
+		}
+	}
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()),
+		WithMiddleware(recordingMiddleware("outer", &events), shortCircuit, recordingMiddleware("inner", &events)))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.Calls(mockserver.EndpointHomeIndex)
+
+	// 直接填充响应：内层中间件和服务器都不会收到请求
+	events = nil
+	mode = "cache"
+	resp, err := c.HomeIndex(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.DailyResetTime != 42 {
+		t.Fatalf("got %+v, want the cached response", resp.Data)
+	}
+
+	// 直接返回错误
+	mode = "fail"
+	if _, err = c.HomeIndex(context.Background()); !errors.Is(err, errInjected) {
+		t.Fatalf("got %v, want the injected error", err)
+	}
+	if ClassifyError(err) != FailureOther {
+		t.Fatalf("injected error classified as %v", ClassifyError(err))
+	}
+	want := []string{"outer:before", "outer:after", "outer:before", "outer:after"}
+	if !slices.Equal(events, want) {
+		t.Fatalf("got %v, want %v", events, want)
+	}
+	if n := srv.Calls(mockserver.EndpointHomeIndex); n != calls {
+		t.Fatalf("home/index sent %d times while short-circuited", n-calls)
+	}
+
+	// 请求链未被打乱，之后的请求正常
+	mode = ""
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
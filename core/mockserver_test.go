@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"gopcr/models"
+	"testing"
+	"time"
+)
+
+func TestSessionExpiredRelogin(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 会话失效时返回错误，下一次请求重新登录
+	srv.ScriptResultCodes(mockserver.EndpointHomeIndex, mockserver.ResultCodeSessionExpired)
+	if _, err := c.HomeIndex(context.Background()); !errors.Is(err, models.ErrSessionExpired) {
+		t.Fatalf("got %v, want ErrSessionExpired", err)
+	}
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 2 {
+		t.Fatalf("sdk_login called %d times, want 2", n)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestDailyResetRelogin(t *testing.T) {
+	// 每日重置时间取整秒，load/index以秒为单位下发
+	reset := time.Now().Add(1500 * time.Millisecond).Truncate(time.Second)
+	srv := mockserver.New(mockserver.WithDailyResetTime(reset))
+	defer srv.Close()
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()))
+	if !c.SessionExpiresAt().IsZero() {
+		t.Fatal("SessionExpiresAt set before login")
+	}
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.SessionExpiresAt(); !got.Equal(reset) {
+		t.Fatalf("SessionExpiresAt %v, want %v", got, reset)
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 1 {
+		t.Fatalf("sdk_login called %d times before the reset, want 1", n)
+	}
+
+	// 越过重置时间后服务器使旧会话失效，客户端在请求前主动重新登录，不返回 ErrSessionExpired
+	next := reset.AddDate(0, 0, 1)
+	srv.SetDailyResetTime(next)
+	time.Sleep(time.Until(reset))
+	srv.ExpireSessions()
+	for range 2 {
+		if _, err := c.HomeIndex(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 2 {
+		t.Fatalf("sdk_login called %d times, want exactly one re-login", n)
+	}
+	if got := c.SessionExpiresAt(); !got.Equal(next) {
+		t.Fatalf("SessionExpiresAt %v after re-login, want %v", got, next)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestLoginRetry(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	// 第一次登录在game_start失败，整个登录流程重试
+	srv.ScriptResultCodes(mockserver.EndpointGameStart, mockserver.ResultCodeSessionExpired)
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 2 {
+		t.Fatalf("sdk_login called %d times, want 2", n)
+	}
+	if n := srv.Calls(mockserver.EndpointGameStart); n != 2 {
+		t.Fatalf("game_start called %d times, want 2", n)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestAppVerRefreshOnVersionUpdated(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	srv.SetAppVer("9.9.9")
+
+	cfg := config.NewBili()
+	c := newTestClient(t, srv, "u1", WithConfig(cfg), WithAppVerURL(srv.AppVersionURL()))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.AppVer(); got != "9.9.9" {
+		t.Fatalf("config AppVer %q, want 9.9.9", got)
+	}
+	if n := srv.Calls(mockserver.EndpointAppVersion); n != 1 {
+		t.Fatalf("app version fetched %d times, want 1", n)
+	}
+	if n := srv.Calls(mockserver.EndpointGameStart); n != 2 {
+		t.Fatalf("game_start called %d times, want 2", n)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	srv.SetMaintenance("维护中")
+
+	acc := SdkAccount{Uid: "u1", AccessKey: "key", Platform: "2", Channel: "1"}
+	_, err := NewClient(context.Background(), acc, WithBaseURL(srv.URL), WithConfig(config.NewBili()))
+	var maintenanceErr *models.MaintenanceError
+	if !errors.As(err, &maintenanceErr) || !errors.Is(err, models.ErrMaintenance) {
+		t.Fatalf("got %v, want *models.MaintenanceError", err)
+	}
+	if maintenanceErr.Message != "维护中" {
+		t.Fatalf("maintenance message %q", maintenanceErr.Message)
+	}
+
+	// 维护结束后可以正常登录，登录后再次维护时请求返回维护错误
+	srv.ClearMaintenance()
+	c := newTestClient(t, srv, "u1", WithConfig(config.NewBili()))
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetMaintenance("临时维护")
+	_, err = c.HomeIndex(context.Background())
+	if !errors.As(err, &maintenanceErr) || maintenanceErr.Message != "临时维护" {
+		t.Fatalf("got %v, want *models.MaintenanceError", err)
+	}
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-resty/resty/v2"
+	"gopcr/log"
+	"gopcr/models"
+	"io"
+	"sync"
+	"time"
+)
+
+// Record 一次请求/响应的明文记录，可由 NewReplayTransport 回放
+type Record struct {
+	Time           time.Time         `json:"time"`
+	DurationMs     float64           `json:"duration_ms"`
+	Method         string            `json:"method"`
+	Path           string            `json:"path"`
+	Encrypted      bool              `json:"encrypted"`
+	RequestHeaders map[string]string `json:"request_headers,omitempty"`
+	Request        any               `json:"request"`            // 请求体明文
+	Status         int               `json:"status,omitempty"`   // HTTP状态码，请求未发出时为0
+	ResultCode     int               `json:"result_code"`        // data_headers.result_code
+	Response       map[string]any    `json:"response,omitempty"` // 响应明文，包含data_headers和data
+	Error          string            `json:"error,omitempty"`
+}
+
+// Recorder 接收每次请求的记录
+type Recorder interface {
+	Record(rec *Record)
+}
+
+// jsonlRecorder 每条记录写为一行JSON
+type jsonlRecorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLRecorder 将记录以JSONL格式写入w，可被多个客户端共用
+func NewJSONLRecorder(w io.Writer) Recorder {
+	return &jsonlRecorder{w: w}
+}
+
+func (r *jsonlRecorder) Record(rec *Record) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		log.Error("序列化请求记录失败: %v", err)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err = r.w.Write(append(line, '\n')); err != nil {
+		log.Error("写入请求记录失败: %v", err)
+	}
+}
+
+// WithRecorder 记录每次请求（包括重试和登录）的明文Option，用于调试和回放
+func WithRecorder(recorder Recorder) SessionOption {
+	return func(client *session) {
+		client.recorder = recorder
+	}
+}
+
+// record 生成一次execReq的记录并交给recorder
+func (s *session) record(request models.IRequest, resp *resty.Response, err error, start time.Time) {
+	rec := &Record{
+		Time:       start,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Method:     request.GetMethod(),
+		Encrypted:  request.IsEncrypt(),
+	}
+	var payload any = request
+	if p, ok := request.(models.PayloadRequest); ok {
+		payload = p.Payload()
+	}
+	rec.Request = redactPayload(payload)
+	if u, urlErr := request.GetUrl(); urlErr == nil {
+		rec.Path = u.Path
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if resp != nil {
+		if resp.Request != nil && resp.Request.RawRequest != nil {
+			rec.RequestHeaders = log.RedactHeaders(resp.Request.RawRequest.Header)
+		}
+		rec.Status = resp.StatusCode()
+		if decoded := s.decodeRecordBody(request.IsEncrypt(), resp.Body()); decoded != nil {
+			rec.Response = log.RedactValue(decoded).(map[string]any)
+		}
+		rec.ResultCode = models.RawResponse(rec.Response).GetResultCode()
+	}
+	s.recorder.Record(rec)
+}
+
+// redactPayload 将请求体转换为JSON值并脱敏（规则同 log.Redact），无法转换时返回nil
+func redactPayload(payload any) any {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded any
+	if err = decoder.Decode(&decoded); err != nil {
+		return nil
+	}
+	return log.RedactValue(decoded)
+}
+
+// decodeRecordBody 将响应体解码为map，无法解码时返回nil
+func (s *session) decodeRecordBody(encrypted bool, body []byte) map[string]any {
+	if len(body) == 0 {
+		return nil
+	}
+	var decoded map[string]any
+	var err error
+	if encrypted {
+		err = s.crypto.DecryptResponse(body, &decoded)
+	} else {
+		err = json.Unmarshal(body, &decoded)
+	}
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"strings"
+	"testing"
+)
+
+// recordSession 登录并调用 home/index，返回写入的记录
+func recordSession(t *testing.T, srv *mockserver.Server, acc SdkAccount) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	c, err := NewClient(context.Background(), acc,
+		WithBaseURL(srv.URL), WithConfig(config.NewBili()), WithRecorder(NewJSONLRecorder(&buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestRecorderRedacts(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	const accessKey = "recorder-secret-access-key"
+	buf := recordSession(t, srv, SdkAccount{Uid: "u1", AccessKey: accessKey, Platform: "2", Channel: "1"})
+	if strings.Contains(buf.String(), accessKey) {
+		t.Fatal("access_key written to the record")
+	}
+
+	records, err := LoadRecords(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, rec := range records {
+		path := strings.Trim(rec.Path, "/")
+		seen[path] = true
+		if !rec.Encrypted {
+			continue
+		}
+		for _, name := range []string{"Sid", "Request-Id"} {
+			if v, ok := rec.RequestHeaders[name]; ok && v != "[REDACTED]" {
+				t.Fatalf("%s: header %s recorded as %q", path, name, v)
+			}
+		}
+		request, _ := rec.Request.(map[string]any)
+		if v := request["viewer_id"]; v != "[REDACTED]" {
+			t.Fatalf("%s: encrypted viewer_id recorded as %v", path, v)
+		}
+		if path == mockserver.EndpointSdkLogin && request["access_key"] != "[REDACTED]" {
+			t.Fatalf("sdk_login access_key recorded as %v", request["access_key"])
+		}
+		headers, _ := rec.Response["data_headers"].(map[string]any)
+		for _, name := range []string{"sid", "request_id"} {
+			if v, ok := headers[name]; ok && v != "[REDACTED]" {
+				t.Fatalf("%s: response %s recorded as %v", path, name, v)
+			}
+		}
+	}
+	for _, path := range []string{mockserver.EndpointSdkLogin, mockserver.EndpointHomeIndex} {
+		if !seen[path] {
+			t.Fatalf("%s was not recorded", path)
+		}
+	}
+}
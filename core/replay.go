@@ -0,0 +1,120 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopcr/pcrcrypto"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// LoadRecords 读取 NewJSONLRecorder 写入的记录
+func LoadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.UseNumber()
+		var rec Record
+		if err := decoder.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("第%d行: %w", line, err)
+		}
+		rec.Response, _ = pcrcrypto.NormalizeNumbers(rec.Response).(map[string]any)
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// replayTransport 按路径依次返回记录的响应
+type replayTransport struct {
+	crypto *pcrcrypto.Codec
+
+	mu      sync.Mutex
+	pending map[string][]Record
+}
+
+// NewReplayTransport 创建回放记录的 http.RoundTripper，配合 WithTransport 使用。
+// 每个路径的请求按顺序取用该路径的下一条记录；没有记录的路径返回404。
+// 请求链不做校验，响应按记录中的明文重新加密
+func NewReplayTransport(records []Record) http.RoundTripper {
+	t := &replayTransport{
+		crypto:  pcrcrypto.New(),
+		pending: make(map[string][]Record),
+	}
+	for _, rec := range records {
+		// 请求未发出的记录没有对应的响应
+		if rec.Status == 0 {
+			continue
+		}
+		path := strings.Trim(rec.Path, "/")
+		t.pending[path] = append(t.pending[path], rec)
+	}
+	return t
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+	path := strings.Trim(req.URL.Path, "/")
+
+	t.mu.Lock()
+	queue := t.pending[path]
+	var rec Record
+	found := len(queue) > 0
+	if found {
+		rec, t.pending[path] = queue[0], queue[1:]
+	}
+	t.mu.Unlock()
+
+	if !found {
+		return replayResponse(req, http.StatusNotFound, []byte("no recorded response for "+path)), nil
+	}
+	body, err := t.encodeBody(rec)
+	if err != nil {
+		return nil, fmt.Errorf("回放 %s 失败: %w", path, err)
+	}
+	return replayResponse(req, rec.Status, body), nil
+}
+
+// encodeBody 按记录的加密方式重新编码响应
+func (t *replayTransport) encodeBody(rec Record) ([]byte, error) {
+	if rec.Response == nil {
+		return nil, nil
+	}
+	if !rec.Encrypted {
+		return json.Marshal(rec.Response)
+	}
+	return t.crypto.EncryptResponse(rec.Response)
+}
+
+func replayResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// WithTransport 自定义HTTP传输层Option，例如 NewReplayTransport
+func WithTransport(transport http.RoundTripper) SessionOption {
+	return func(client *session) {
+		client.httpClient.SetTransport(transport)
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"reflect"
+	"testing"
+)
+
+func TestReplayTransport(t *testing.T) {
+	srv := mockserver.New()
+	acc := SdkAccount{Uid: "u1", AccessKey: "key", Platform: "2", Channel: "1"}
+	var buf bytes.Buffer
+	c, err := NewClient(context.Background(), acc,
+		WithBaseURL(srv.URL), WithConfig(config.NewBili()), WithRecorder(NewJSONLRecorder(&buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := c.HomeIndex(context.Background())
+	c.Close()
+	srv.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := LoadRecords(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 服务器已关闭，登录和 home/index 都由记录回放
+	replay, err := NewClient(context.Background(), acc, WithBaseURL("http://replay.invalid/"),
+		WithConfig(config.NewBili()), WithTransport(NewReplayTransport(records)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replay.Close()
+	replayed, err := replay.HomeIndex(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed.Data, recorded.Data) {
+		t.Fatalf("replayed %+v, recorded %+v", replayed.Data, recorded.Data)
+	}
+	if replay.ExportState().ViewerId != srv.ViewerId("u1") {
+		t.Fatalf("replayed viewer_id %d", replay.ExportState().ViewerId)
+	}
+
+	// 记录用完后返回404
+	if _, err = replay.HomeIndex(context.Background()); err == nil {
+		t.Fatal("expected an error after the records were used up")
+	}
+}
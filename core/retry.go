@@ -0,0 +1,230 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"gopcr/models"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// FailureKind execReq 失败的分类
+type FailureKind int
+
+const (
+	FailureNone        FailureKind = iota // 成功
+	FailureNetwork                        // 网络错误（连接失败、超时等）
+	FailureHTTP5xx                        // HTTP 5xx
+	FailureHTTPStatus                     // 其他非200的HTTP状态码
+	FailureDecrypt                        // 响应解密失败
+	FailureResultCode                     // API返回非成功的结果码
+	FailureMaintenance                    // 服务器维护中
+	FailureOther                          // 其他错误（请求准备失败、版本更新等）
+)
+
+var failureKindNames = map[FailureKind]string{
+	FailureNone:        "none",
+	FailureNetwork:     "network",
+	FailureHTTP5xx:     "http_5xx",
+	FailureHTTPStatus:  "http_status",
+	FailureDecrypt:     "decrypt",
+	FailureResultCode:  "result_code",
+	FailureMaintenance: "maintenance",
+	FailureOther:       "other",
+}
+
+func (k FailureKind) String() string {
+	if name, ok := failureKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ClassifyError 对 execReq 返回的错误进行分类
+func ClassifyError(err error) FailureKind {
+	if err == nil {
+		return FailureNone
+	}
+	var apiErr *models.ApiError
+	if !errors.As(err, &apiErr) {
+		return FailureOther
+	}
+	switch apiErr.Operation {
+	case "execReq:Execute":
+		return FailureNetwork
+	case "execReq:HttpStatus":
+		if apiErr.HTTPStatus >= http.StatusInternalServerError {
+			return FailureHTTP5xx
+		}
+		return FailureHTTPStatus
+	case "execReq:DecryptData":
+		return FailureDecrypt
+	case "execReq:ResultCode":
+		if errors.Is(apiErr, models.ErrMaintenance) {
+			return FailureMaintenance
+		}
+		return FailureResultCode
+	}
+	return FailureOther
+}
+
+// Attempt 一次请求尝试的信息，传给 RetryPolicy.OnAttempt
+type Attempt struct {
+	Request  models.IRequest
+	Host     string        // 本次尝试使用的API根地址
+	Number   int           // 第几次尝试，从1开始
+	Err      error         // 本次尝试的错误，成功时为nil
+	Kind     FailureKind   // 错误分类
+	Duration time.Duration // 本次尝试耗时
+	Delay    time.Duration // 下一次重试前的等待时间，不再重试时为0
+}
+
+// RetryPolicy 请求重试策略。
+// 重试时不会推进请求链：REQUEST-ID/SID只在成功时更新，因此重试的请求携带与首次相同的请求ID
+type RetryPolicy struct {
+	MaxAttempts int           // 最大尝试次数（含首次），<=1 表示不重试
+	BaseDelay   time.Duration // 首次重试前的等待时间
+	MaxDelay    time.Duration // 等待时间上限，0表示不限制
+	Multiplier  float64       // 每次重试等待时间的倍数，<=1 时取2
+	Jitter      float64       // 随机抖动比例，取值0~1
+
+	// Retryable 判断失败是否可重试，为nil时使用 DefaultRetryable
+	Retryable func(kind FailureKind, err error) bool
+	// OnAttempt 每次尝试结束后调用（包括成功的尝试）
+	OnAttempt func(attempt Attempt)
+}
+
+// DefaultRetryable 网络错误、HTTP 5xx和解密失败可以重试
+func DefaultRetryable(kind FailureKind, _ error) bool {
+	switch kind {
+	case FailureNetwork, FailureHTTP5xx, FailureDecrypt:
+		return true
+	}
+	return false
+}
+
+// DefaultRetryPolicy 推荐的重试策略：最多3次，200ms起指数退避，上限5s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Multiplier:  2,
+		Jitter:      0.2,
+	}
+}
+
+// WithRetryPolicy 重试策略Option，默认不重试
+func WithRetryPolicy(policy RetryPolicy) SessionOption {
+	return func(client *session) {
+		client.retryPolicy = policy
+	}
+}
+
+func (p RetryPolicy) retryable(kind FailureKind, err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(kind, err)
+	}
+	return DefaultRetryable(kind, err)
+}
+
+// backoff 计算第n次尝试失败后的等待时间
+func (p RetryPolicy) backoff(n int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	delay := float64(p.BaseDelay)
+	for i := 1; i < n; i++ {
+		delay *= multiplier
+		if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		// 在 [1-Jitter, 1+Jitter] 范围内随机
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// execWithRetry 按重试策略执行 execReq。
+// 连接失败或5xx时先依次切换到其他候选地址立即重试；收到204并更新AppVer后立即重试一次。
+// 这些尝试不计入 MaxAttempts
+func (s *session) execWithRetry(
+	ctx context.Context,
+	request models.IRequest,
+	result models.IResponse,
+) (*resty.Response, error) {
+	policy := s.retryPolicy
+	free := 0 // 不计入 MaxAttempts 的尝试次数
+	failovers := 0
+	appVerUpdated := false
+	for n := 1; ; n++ {
+		start := time.Now()
+		host := s.httpClient.BaseURL
+		resp, err := s.execReq(ctx, request, result)
+		if s.recorder != nil {
+			s.record(request, resp, err, start)
+		}
+		kind := ClassifyError(err)
+
+		failover := (kind == FailureNetwork || kind == FailureHTTP5xx) &&
+			failovers < s.hosts.len()-1 && ctx.Err() == nil
+		// AppVer已更新，用新的APP-VER重新发送原请求
+		updated := !appVerUpdated && isAppVerUpdated(err) && ctx.Err() == nil
+		retry := err != nil && !failover && !updated && n-free < policy.MaxAttempts &&
+			ctx.Err() == nil && policy.retryable(kind, err)
+		var delay time.Duration
+		if retry {
+			delay = policy.backoff(n - free)
+		}
+		if policy.OnAttempt != nil {
+			policy.OnAttempt(Attempt{
+				Request:  request,
+				Host:     host,
+				Number:   n,
+				Err:      err,
+				Kind:     kind,
+				Duration: time.Since(start),
+				Delay:    delay,
+			})
+		}
+		if failover {
+			free++
+			failovers++
+			next := s.failover()
+			s.requestLogger(request).Debug("API地址不可用，切换地址", "host", host, "kind", kind.String(), "next", next)
+			continue
+		}
+		if updated {
+			free++
+			appVerUpdated = true
+			s.requestLogger(request).Debug("AppVer已更新，重新发送请求")
+			continue
+		}
+		if !retry {
+			return resp, err
+		}
+
+		s.requestLogger(request).Debug("请求失败，等待重试", "attempt", n, "kind", kind.String(), "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		}
+	}
+}
+
+// isAppVerUpdated 是否为 execReq 收到204并已更新AppVer的错误
+func isAppVerUpdated(err error) bool {
+	var apiErr *models.ApiError
+	return errors.As(err, &apiErr) && apiErr.Operation == "execReq:UpdateAppVer"
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"gopcr/models"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// retryTest 通过前置服务器连接模拟服务器的已登录客户端
+type retryTest struct {
+	srv      *mockserver.Server
+	client   *Client
+	broken   *atomic.Bool // 为true时前置服务器返回503
+	attempts []Attempt    // 登录之后的尝试记录
+}
+
+// newRetryTest 创建使用policy的客户端并登录。onAttempt 在记录每次尝试后调用，可为nil
+func newRetryTest(t *testing.T, policy RetryPolicy, onAttempt func(rt *retryTest, attempt Attempt)) *retryTest {
+	t.Helper()
+	rt := &retryTest{srv: mockserver.New()}
+	t.Cleanup(rt.srv.Close)
+	front, broken := frontend(t, rt.srv, 0)
+	rt.broken = broken
+	policy.OnAttempt = func(attempt Attempt) {
+		rt.attempts = append(rt.attempts, attempt)
+		if onAttempt != nil {
+			onAttempt(rt, attempt)
+		}
+	}
+
+	acc := SdkAccount{Uid: "u1", AccessKey: "key", Platform: "2", Channel: "1"}
+	c, err := NewClient(context.Background(), acc,
+		WithHosts(front.URL), WithConfig(config.NewBili()), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rt.client = c
+	rt.attempts = nil
+	return rt
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	rt := newRetryTest(t, RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, Multiplier: 2}, nil)
+
+	// 持续503时共尝试MaxAttempts次，等待时间按倍数增长，最后一次不再等待
+	rt.broken.Store(true)
+	if _, err := rt.client.HomeIndex(context.Background()); ClassifyError(err) != FailureHTTP5xx {
+		t.Fatalf("got %v, want an HTTP 5xx error", err)
+	}
+	wantDelays := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 0}
+	if len(rt.attempts) != len(wantDelays) {
+		t.Fatalf("%d attempts, want %d", len(rt.attempts), len(wantDelays))
+	}
+	for i, attempt := range rt.attempts {
+		if attempt.Number != i+1 || attempt.Kind != FailureHTTP5xx || attempt.Delay != wantDelays[i] {
+			t.Fatalf("attempt %d: %+v", i+1, attempt)
+		}
+	}
+}
+
+func TestRetryPolicyRecovers(t *testing.T) {
+	rt := newRetryTest(t, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}, func(rt *retryTest, attempt Attempt) {
+		// 第二次尝试失败后恢复
+		if attempt.Number == 2 {
+			rt.broken.Store(false)
+		}
+	})
+	rt.broken.Store(true)
+	if _, err := rt.client.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rt.attempts); n != 3 || rt.attempts[2].Err != nil {
+		t.Fatalf("%d attempts, want 3 with the last one succeeding", n)
+	}
+	if errs := rt.srv.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	// 只重试结果码错误，HTTP 5xx不重试
+	rt := newRetryTest(t, RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		Retryable: func(kind FailureKind, err error) bool {
+			return kind == FailureResultCode
+		},
+	}, nil)
+
+	rt.broken.Store(true)
+	if _, err := rt.client.HomeIndex(context.Background()); ClassifyError(err) != FailureHTTP5xx {
+		t.Fatalf("got %v, want an HTTP 5xx error", err)
+	}
+	if n := len(rt.attempts); n != 1 {
+		t.Fatalf("%d attempts for a non-retryable error, want 1", n)
+	}
+
+	rt.broken.Store(false)
+	rt.attempts = nil
+	rt.srv.ScriptResultCodes(mockserver.EndpointHomeIndex, models.ResultCodeServerBusy)
+	if _, err := rt.client.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rt.attempts); n != 2 || rt.attempts[0].Kind != FailureResultCode {
+		t.Fatalf("attempts %+v, want a retried result code error", rt.attempts)
+	}
+}
+
+func TestRetryPolicyCancelDuringBackoff(t *testing.T) {
+	rt := newRetryTest(t, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}, nil)
+	rt.broken.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := rt.client.HomeIndex(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("HomeIndex returned after %v, want it to stop waiting when ctx is done", elapsed)
+	}
+	if n := len(rt.attempts); n != 1 || rt.attempts[0].Delay != time.Hour {
+		t.Fatalf("attempts %+v, want one attempt waiting to retry", rt.attempts)
+	}
+}
+
+func TestRetryPolicyBackoffLimits(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 3}
+	for n, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 300 * time.Millisecond,
+		3: 900 * time.Millisecond,
+		4: time.Second,
+		9: time.Second,
+	} {
+		if got := p.backoff(n); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+
+	// 抖动在 [1-Jitter, 1+Jitter] 范围内
+	p = RetryPolicy{BaseDelay: 100 * time.Millisecond, Jitter: 0.2}
+	for range 100 {
+		if got := p.backoff(1); got < 80*time.Millisecond || got > 120*time.Millisecond {
+			t.Fatalf("backoff(1) = %v with 20%% jitter", got)
+		}
+	}
+}
@@ -6,12 +6,18 @@ import (
 	"errors"
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"gopcr/captcha"
 	"gopcr/config"
 	"gopcr/log"
 	"gopcr/models"
+	"gopcr/pcrcrypto"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type SdkAccount struct {
@@ -27,41 +33,110 @@ type session struct {
 	ctx        context.Context    // 内部创建的 context
 	ctxCancel  context.CancelFunc // 用于取消 context
 	httpClient *resty.Client
-	crypto     *pcrCrypto
+	crypto     *pcrcrypto.Codec
 	sdkAccount SdkAccount
 	logged     bool
 	viewerId   uint64
-	expireTime uint
+	expireTime atomic.Uint64 // 每日重置时间，可在请求进行中读取
+	resumed    bool          // 是否由保存的状态恢复，且尚未验证过请求链
+
+	retryPolicy    RetryPolicy        // 请求重试策略
+	captchaSolver  captcha.Solver     // 验证码求解器，为nil时触发风控直接失败
+	captchaFetcher captcha.Fetcher    // 获取验证码挑战，与captchaSolver同时设置
+	appVerProvider AppVersionProvider // 获取最新AppVer
+	recorder       Recorder           // 请求记录，为nil时不记录
+	hosts          hostList           // API根地址的候选列表
+	config         *config.Config     // APP-VER等请求头、API主机和超时时间
+	channel        bool               // 是否为渠道服
+	device         *DeviceProfile     // 设备信息，为nil时使用默认值
+	middlewares    []Middleware       // 请求中间件，先添加的位于外层
+	logger         *slog.Logger       // 带有account属性的日志，已脱敏
+	handler        Handler            // 组合后的中间件链
+
+	maintenancePolicy MaintenancePolicy // WaitForService 的轮询间隔
+
+	// reqSem 串行化请求链（包括登录），容量为1。
+	// REQUEST-ID/SID等头在请求间传递，并发请求会破坏服务器的请求链
+	reqSem chan struct{}
 }
 
 // SessionOption 定义客户端选项
 type SessionOption func(*session)
 
-// WithChannelServer 渠道服Option
+// WithChannelServer 渠道服Option，未指定 WithConfig 时使用 config.DefaultChannel()
 func WithChannelServer() SessionOption {
 	return func(client *session) {
-		client.httpClient.
-			SetBaseURL("https://"+config.DefaultChannelApiHost).
-			SetHeader("PLATFORM-ID", "4").
-			SetHeaders(config.GetBiliHeaders())
+		client.channel = true
+		client.httpClient.SetHeader("PLATFORM-ID", "4")
+	}
+}
+
+// WithConfig 指定客户端配置Option，默认为进程内共用的 config.Default()（渠道服为 config.DefaultChannel()）。
+// 需要与其他客户端隔离时传入独立的配置，例如 config.NewBili() 或 config.Default().Clone()
+func WithConfig(cfg *config.Config) SessionOption {
+	return func(client *session) {
+		client.config = cfg
+	}
+}
+
+// WithBaseURL 自定义API根地址Option，例如指向本地的模拟服务器。
+// 之后仍会使用 source_ini/index 返回的服务器列表，需要固定地址时使用 WithHosts
+func WithBaseURL(baseURL string) SessionOption {
+	return func(client *session) {
+		client.httpClient.SetBaseURL(baseURL)
+	}
+}
+
+// WithLogger 指定客户端的日志Option，默认写入全局日志（gopcr/log）。
+// 日志带有account属性，请求相关的日志还带有viewer_id和endpoint属性；
+// access_key、SID和加密后的viewer_id会被自动脱敏
+func WithLogger(logger *slog.Logger) SessionOption {
+	return func(client *session) {
+		if logger != nil {
+			client.logger = slog.New(log.Redact(logger.Handler()))
+		}
+	}
+}
+
+// WithCaptchaSolver 验证码Option，sdk_login触发风控时由fetcher获取挑战、solver求解。
+// B服的挑战需要签名请求，通常使用 bsdk.Client 作为fetcher
+func WithCaptchaSolver(fetcher captcha.Fetcher, solver captcha.Solver) SessionOption {
+	return func(client *session) {
+		client.captchaFetcher = fetcher
+		client.captchaSolver = solver
 	}
 }
 
 // newSession 创建一个新的Client。
-// 默认为B服，渠道服用上面的Option。
-func newSession(sdkAccount SdkAccount, options ...SessionOption) (*session, error) {
+// 默认为B服，渠道服用上面的Option。ctx仅作用于创建过程中的请求
+func newSession(ctx context.Context, sdkAccount SdkAccount, options ...SessionOption) (*session, error) {
+	client := buildSession(sdkAccount, options...)
 
-	// 创建一个带有取消功能的 context
-	ctx, cancel := context.WithCancel(context.Background())
+	//
+	//err := httpClient.getConfig()
+	//if err != nil {
+	//	return nil, fmt.Errorf("配置失败: %w", err)
+	//}
+	reqCtx, reqCancel := client.requestCtx(ctx)
+	defer reqCancel()
+	err := client.getConfig(reqCtx)
+	if err != nil {
+		client.ctxCancel()
+		return nil, err
+	}
+	return client, nil
+}
+
+// buildSession 创建session并应用选项，不发送任何请求
+func buildSession(sdkAccount SdkAccount, options ...SessionOption) *session {
+	// 创建一个带有取消功能的 context，作为会话的生命周期，Close时取消
+	sessionCtx, cancel := context.WithCancel(context.Background())
 	// 创建并配置 HTTP 客户端
+	// 根地址、超时和固定请求头来自配置，见 execReq
 	httpClient := resty.New().
-		SetBaseURL("https://" + config.DefaultBiliApiHost).
 		// Debug
 		//SetProxy("http://127.0.0.1:8516").
-		// 设置默认超时等
-		SetTimeout(config.DefaultRequestTimeout).
 		// 设置Headers
-		SetHeaders(config.GetBiliHeaders()).
 		SetHeaders(map[string]string{
 			"PLATFORM":    sdkAccount.Platform,
 			"PLATFORM-ID": sdkAccount.Platform,
@@ -71,34 +146,86 @@ func newSession(sdkAccount SdkAccount, options ...SessionOption) (*session, erro
 	// 使用默认配置创建客户端
 	client := &session{
 		httpClient: httpClient,
-		ctx:        ctx,
+		ctx:        sessionCtx,
 		ctxCancel:  cancel,
 		viewerId:   0,
 		sdkAccount: sdkAccount,
 		logged:     false,
-		crypto:     newPCRCrypto(),
+		crypto:     pcrcrypto.New(),
+		reqSem:     make(chan struct{}, 1),
+
+		maintenancePolicy: defaultMaintenancePolicy,
+
+		appVerProvider: &BiligameAppVersion{},
+		logger:         log.Default(),
 	}
 
 	// 应用选项
 	for _, option := range options {
 		option(client)
 	}
+	if client.config == nil {
+		if client.channel {
+			client.config = config.DefaultChannel()
+		} else {
+			client.config = config.Default()
+		}
+	}
+	// 未通过选项指定地址时使用配置中的地址
+	if httpClient.BaseURL == "" {
+		if hosts := client.config.Hosts(); len(hosts) > 0 {
+			WithHosts(hosts...)(client)
+		} else {
+			httpClient.SetBaseURL("https://" + client.config.APIHost())
+		}
+	}
+	// 使用保存的AppVer，避免每次启动都先收到204
+	if cache, ok := client.appVerProvider.(AppVersionCache); ok {
+		if ver := cache.CachedAppVersion(); ver != "" {
+			client.config.SetAppVer(ver)
+		}
+	}
+	client.logger = client.logger.With("account", sdkAccount.Uid)
+	client.handler = client.buildHandler()
+	// 未指定候选列表时，以初始地址作为唯一候选，直到获取到服务器列表
+	if client.hosts.len() == 0 {
+		client.hosts.set([]string{httpClient.BaseURL})
+	}
+	return client
+}
 
-	//
-	//err := httpClient.getConfig()
-	//if err != nil {
-	//	return nil, fmt.Errorf("配置失败: %w", err)
-	//}
-	err := client.getConfig()
-	if err != nil {
-		return nil, err
+// acquire 获取请求链的执行权，ctx取消时放弃等待
+func (s *session) acquire(ctx context.Context) error {
+	select {
+	case s.reqSem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return client, nil
 }
 
-func (s *session) preReq(request models.IRequest) (*resty.Request, error) {
+// release 释放请求链的执行权
+func (s *session) release() {
+	<-s.reqSem
+}
+
+// requestCtx 合并调用方ctx与会话生命周期ctx，任一取消都会中止请求
+func (s *session) requestCtx(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(s.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+func (s *session) preReq(ctx context.Context, request models.IRequest) (*resty.Request, error) {
 	req := s.httpClient.R().
-		SetContext(s.ctx)
+		SetContext(ctx)
+	var payload any = request
+	if p, ok := request.(models.PayloadRequest); ok {
+		payload = p.Payload()
+	}
 
 	if request.IsEncrypt() {
 		encryptViewerId, err := s.crypto.EncryptViewerId(s.viewerId)
@@ -107,7 +234,7 @@ func (s *session) preReq(request models.IRequest) (*resty.Request, error) {
 		}
 		request.SetViewerId(encryptViewerId)
 
-		data, err := s.crypto.EncryptData(request)
+		data, err := s.crypto.EncryptData(payload)
 		if err != nil {
 			return nil, fmt.Errorf("加密数据失败: %w", err)
 		}
@@ -115,7 +242,7 @@ func (s *session) preReq(request models.IRequest) (*resty.Request, error) {
 	} else {
 		request.SetViewerId(strconv.FormatUint(uint64(s.viewerId), 10))
 
-		jsonData, err := json.Marshal(request)
+		jsonData, err := json.Marshal(payload)
 		if err != nil {
 			return nil, fmt.Errorf("序列化数据失败: %w", err)
 		}
@@ -127,37 +254,129 @@ func (s *session) preReq(request models.IRequest) (*resty.Request, error) {
 	return req, nil
 }
 
-// execReq 执行HTTP请求。result必须传指针
+// execReq 执行一次HTTP请求，不重试。result必须传指针
 func (s *session) execReq(
+	ctx context.Context,
 	request models.IRequest,
 	result models.IResponse,
 ) (*resty.Response, error) {
-	var err error
+	// 配置中的固定请求头在每次请求时读取，其他客户端更新的AppVer立即生效
+	header := make(http.Header)
+	for key, value := range s.config.Headers() {
+		header.Set(key, value)
+	}
+	for key, values := range s.httpClient.Header {
+		header[key] = slices.Clone(values)
+	}
+	ex := &Exchange{
+		Request:  request,
+		Response: result,
+		Header:   header,
+		Host:     s.httpClient.BaseURL,
+		Start:    time.Now(),
+		Logger:   s.requestLogger(request),
+	}
+	if err := s.handler(ctx, ex); err != nil {
+		return ex.resp, err
+	}
+	resp := ex.resp
+
+	// 版本号需要更新
+	if result.GetResultCode() == models.ResultCodeVersionUpdated && isGameStart(request) {
+		newAppVer, err := discoverAppVer(ctx, s.config, s.appVerProvider, ex.Header.Get("APP-VER"))
+		if err != nil {
+			return resp, &models.ApiError{
+				Operation: "execReq:getNewAppVer",
+				Message:   "获取新版本号失败",
+				Err:       err,
+			}
+		}
+		s.config.SetAppVer(newAppVer)
+		// 恢复会话时设置的APP-VER已过时，之后使用配置中的版本
+		s.httpClient.Header.Del("APP-VER")
+		ex.Logger.Debug("已更新AppVer", "app_ver", newAppVer)
+		return resp, &models.ApiError{
+			Operation: "execReq:UpdateAppVer",
+			Message:   "已更新AppVer",
+			ApiCode:   result.GetResultCode(),
+		}
+	}
+
+	if result.GetResultCode() != models.ResultCodeSuccess {
+		ex.Logger.Error("API失败", "result_code", result.GetResultCode())
+		return resp, &models.ApiError{
+			Operation: "execReq:ResultCode",
+			Message:   "API失败",
+			ApiCode:   result.GetResultCode(),
+		}
+	}
+
+	// 请求后处理
+	if reqId := result.GetRequestId(); reqId != "" {
+		s.httpClient.SetHeader("REQUEST-ID", reqId)
+	}
+	if sid := result.GetSID(); sid != "" {
+		s.httpClient.SetHeader("SID", pcrcrypto.CalcSID(sid))
+	}
+
+	return resp, nil
+}
+
+// requestLogger 返回带有viewer_id和endpoint属性的日志
+func (s *session) requestLogger(request models.IRequest) *slog.Logger {
+	logger := s.logger.With("viewer_id", s.viewerId)
+	if u, err := request.GetUrl(); err == nil {
+		logger = logger.With("endpoint", u.Path)
+	}
+	return logger
+}
+
+// isGameStart 是否为 check/game_start 请求，只有该接口的204表示需要更新AppVer
+func isGameStart(request models.IRequest) bool {
+	u, err := request.GetUrl()
+	return err == nil && strings.Contains(u.Path, "check/game_start")
+}
+
+// roundTrip 中间件链末端的Handler：准备请求、发送并解密响应
+func (s *session) roundTrip(ctx context.Context, ex *Exchange) error {
+	request, result := ex.Request, ex.Response
+
+	// 单次请求的超时时间，重试时重新计时
+	if timeout := s.config.Timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
 	// 请求预处理
-	req, err := s.preReq(request)
+	req, err := s.preReq(ctx, request)
 	if err != nil {
-		log.Error("准备请求失败: %v", err)
-		return nil, &models.ApiError{
+		ex.Logger.Error("准备请求失败", "error", err)
+		return &models.ApiError{
 			Operation: "execReq:preReq",
 			Message:   "准备请求失败",
 			Err:       err,
 		}
 	}
-	//if s.requestId != "" {
-	//	req.SetHeader("REQUEST-ID", s.requestId)
-	//}
+	for key, values := range ex.Header {
+		req.Header[key] = values
+	}
 	url, err := request.GetUrl()
 	if err != nil {
-		log.Error("获取URL失败: %v", err)
-		return nil, &models.ApiError{
+		ex.Logger.Error("获取URL失败", "error", err)
+		return &models.ApiError{
 			Operation: "execReq:GetUrl",
 			Message:   "获取URL失败",
 			Err:       err,
 		}
 	}
 
-	log.Debug("发送请求: %s", url.String())
+	ex.Logger.Debug("发送请求", "url", url.String())
+
+	start := time.Now()
+	defer func() {
+		ex.Duration = time.Since(start)
+	}()
 
 	var resp *resty.Response
 
@@ -169,10 +388,15 @@ func (s *session) execReq(
 		// 非加密请求使用resty自动解析
 		resp, err = req.SetResult(result).Execute(request.GetMethod(), url.String())
 	}
+	ex.resp = resp
+	if resp != nil && resp.RawResponse != nil {
+		ex.StatusCode = resp.StatusCode()
+		ex.ResponseHeader = resp.Header()
+	}
 
 	if err != nil {
-		log.Error("请求发送失败: %v", err)
-		return resp, &models.ApiError{
+		ex.Logger.Error("请求发送失败", "error", err)
+		return &models.ApiError{
 			Operation: "execReq:Execute",
 			Message:   "请求发送失败",
 			Err:       err,
@@ -180,101 +404,54 @@ func (s *session) execReq(
 
 	}
 
-	log.Debug("收到响应: 状态码=%d, 内容长度=%d", resp.StatusCode(), len(resp.Body()))
+	ex.Logger.Debug("收到响应", "status", resp.StatusCode(), "length", len(resp.Body()))
 
 	if resp.StatusCode() != http.StatusOK {
-		log.Error("HTTP失败，状态码: %d", resp.StatusCode())
-		return resp, &models.ApiError{
+		ex.Logger.Error("HTTP失败", "status", resp.StatusCode())
+		return &models.ApiError{
 			Operation:  "execReq:HttpStatus",
 			Message:    "HTTP失败",
-			Err:        err,
 			HTTPStatus: resp.StatusCode(),
 		}
 	}
 
 	// 对加密响应进行解密处理
 	if request.IsEncrypt() {
-		if err = s.crypto.DecryptData(string(resp.Body()), result); err != nil {
-			log.Error("响应解密失败: %v", err)
-			return resp, &models.ApiError{
+		if err = s.crypto.DecryptResponse(resp.Body(), result); err != nil {
+			ex.Logger.Error("响应解密失败", "error", err)
+			return &models.ApiError{
 				Operation: "execReq:DecryptData",
 				Message:   "响应解密失败",
 				Err:       err,
 			}
 		}
 	}
-
-	// 版本号需要更新
-	if result.GetResultCode() == 204 && strings.Contains(req.URL, "check/game_start") {
-		var newAppVer string
-		newAppVer, err = getNewAppVer()
-		if err != nil {
-			return resp, &models.ApiError{
-				Operation: "execReq:getNewAppVer",
-				Message:   "获取新版本号失败",
-				Err:       err,
-			}
-		}
-		err = config.GetInstance().SetOptVal(config.AppVer, newAppVer)
-		if err != nil {
-			return resp, &models.ApiError{
-				Operation: "execReq:SetOptVal",
-				Message:   "更新AppVer失败",
-				Err:       err,
-			}
-		}
-		s.httpClient.SetHeader("APP-VER", newAppVer)
-		log.Debug("已更新AppVer: %s", newAppVer)
-		return resp, &models.ApiError{
-			Operation: "execReq:UpdateAppVer",
-			Message:   "已更新AppVer",
-			ApiCode:   result.GetResultCode(),
-		}
-	}
-
-	if result.GetResultCode() != 1 {
-		log.Error("API失败，结果码: %d", result.GetResultCode())
-		return resp, &models.ApiError{
-			Operation: "execReq:ResultCode",
-			Message:   "API失败",
-			ApiCode:   result.GetResultCode(),
-		}
-	}
-
-	// 请求后处理
-	if reqId := result.GetRequestId(); reqId != "" {
-		s.httpClient.SetHeader("REQUEST-ID", reqId)
-	}
-	if sid := result.GetSID(); sid != "" {
-		s.httpClient.SetHeader("SID", calcSID(sid))
-	}
-
-	return resp, nil
+	return nil
 }
 
-func (s *session) getConfig() error {
+func (s *session) getConfig(ctx context.Context) error {
 	var err error
 
 	indexReq := models.NewSourceIniIndexReq()
 	var indexResult models.BaseResponse[models.SourceIniIndexResp]
 
-	if _, err = s.execReq(&indexReq, &indexResult); err != nil {
+	if _, err = s.execWithRetry(ctx, &indexReq, &indexResult); err != nil {
 		return err
 	}
-	maintenanceReq := models.NewSourceIniGetMaintenanceStatusReq()
-	var maintenanceResult models.BaseResponse[models.SourceIniGetMaintenanceStatusResp]
+	s.useServerList(ctx, indexResult.Data.Server)
 
-	if _, err = s.execReq(&maintenanceReq, &maintenanceResult); err != nil {
+	status, err := s.maintenanceStatus(ctx)
+	if err != nil {
 		return err
 	}
-	if maintenanceResult.Data.ManifestVer != "" {
-		s.httpClient.SetHeader("MANIFEST-VER", maintenanceResult.Data.ManifestVer)
+	if status.ManifestVer != "" {
+		s.httpClient.SetHeader("MANIFEST-VER", status.ManifestVer)
 	}
 
 	return nil
 }
 
-func (s *session) login() error {
+func (s *session) login(ctx context.Context) error {
 	// 登录流程：
 	// sdk_login: 用bsdk拿到的uid和access_key来登录，暂未测试uid是否必需。完成后拿到滚动req id，放headers
 	// game_start: 用uid和viewer_id来启动游戏
@@ -283,21 +460,21 @@ func (s *session) login() error {
 	if s.logged {
 		return errors.New("已经登录")
 	}
-	// 登录逻辑 尝试3次
+	// 登录逻辑 尝试3次，记录每次的错误
+	loginErr := &models.LoginError{Uid: s.sdkAccount.Uid}
 	for i := range 3 {
+		// 调用方取消时立即中止重试
+		if err := ctx.Err(); err != nil {
+			loginErr.Attempts = append(loginErr.Attempts, err)
+			return loginErr
+		}
 		//err := s.innerLogin()
-		log.Debug("%s 第%d次尝试登录", s.sdkAccount.Uid, i+1)
+		s.logger.Debug("尝试登录", "attempt", i+1)
 		err := func(c *session) error {
 			var err error
 
-			loginReq := models.NewSdkLoginReq()
-			loginReq.Uid = c.sdkAccount.Uid
-			loginReq.AccessKey = c.sdkAccount.AccessKey
-			loginReq.Platform = c.sdkAccount.Platform
-			loginReq.ChannelId = c.sdkAccount.Channel
-			var loginResult models.BaseResponse[models.SdkLoginResp]
-
-			if _, err = c.execReq(&loginReq, &loginResult); err != nil {
+			loginResult, err := c.sdkLogin(ctx)
+			if err != nil {
 				return err
 			}
 			c.viewerId = loginResult.DataHeaders.ViewerId
@@ -305,20 +482,23 @@ func (s *session) login() error {
 			startReq := models.NewGameStartReq()
 			var startResult models.BaseResponse[models.GameStartResp]
 
-			if _, err = c.execReq(&startReq, &startResult); err != nil {
+			if _, err = c.execWithRetry(ctx, &startReq, &startResult); err != nil {
 				return err
 			}
 			if !startResult.Data.NowTutorial {
-				return errors.New("账号还没过教程！")
+				return models.ErrTutorialNotFinished
 			}
 
 			loadIndexReq := models.NewLoadIndexReq()
+			if c.device != nil && c.device.Carrier != "" {
+				loadIndexReq.Carrier = c.device.Carrier
+			}
 			var loadIndexResult models.BaseResponse[models.LoadIndexResp]
 
-			if _, err = c.execReq(&loadIndexReq, &loadIndexResult); err != nil {
+			if _, err = c.execWithRetry(ctx, &loadIndexReq, &loadIndexResult); err != nil {
 				return err
 			}
-			c.expireTime = loadIndexResult.Data.DailyResetTime
+			c.expireTime.Store(uint64(loadIndexResult.Data.DailyResetTime))
 
 			homeIndexReq := models.NewHomeIndexReq()
 			homeIndexReq.MessageId = 1
@@ -327,40 +507,149 @@ func (s *session) login() error {
 			homeIndexReq.TipsIdList = []int{}
 			var homeIndexResult models.BaseResponse[models.HomeIndexResp]
 
-			if _, err = c.execReq(&homeIndexReq, &homeIndexResult); err != nil {
+			if _, err = c.execWithRetry(ctx, &homeIndexReq, &homeIndexResult); err != nil {
 				return err
 			}
 			return nil
 		}(s)
 		if err != nil {
-			log.Error("%s 第%d次登录失败: %v", s.sdkAccount.Uid, i+1, err)
+			s.logger.Error("登录失败", "attempt", i+1, "error", err)
+			// 维护中重试没有意义
+			if errors.Is(err, models.ErrMaintenance) {
+				loginErr.Attempts = append(loginErr.Attempts, s.toMaintenanceError(ctx, err))
+				return loginErr
+			}
+			loginErr.Attempts = append(loginErr.Attempts, err)
+			if ctx.Err() != nil || !retryableLogin(err, s.captchaSolver != nil) {
+				return loginErr
+			}
 			continue
 		}
 		s.logged = true
 		return nil
 	}
 
-	return errors.New("登录失败")
+	return loginErr
+}
+
+// retryableLogin 登录失败后是否值得重试。
+// 未过教程、没有求解器时触发风控，重新登录也不会改变结果
+func retryableLogin(err error, hasSolver bool) bool {
+	if errors.Is(err, models.ErrTutorialNotFinished) {
+		return false
+	}
+	if errors.Is(err, models.ErrRiskControl) && !hasSolver {
+		return false
+	}
+	return true
+}
+
+// sdkLogin 执行sdk_login。触发风控时获取验证码，交给求解器后携带结果重新登录
+func (s *session) sdkLogin(ctx context.Context) (*models.BaseResponse[models.SdkLoginResp], error) {
+	loginReq := models.NewSdkLoginReq()
+	loginReq.Uid = s.sdkAccount.Uid
+	loginReq.AccessKey = s.sdkAccount.AccessKey
+	loginReq.Platform = s.sdkAccount.Platform
+	loginReq.ChannelId = s.sdkAccount.Channel
+	var loginResult models.BaseResponse[models.SdkLoginResp]
+
+	if _, err := s.execWithRetry(ctx, &loginReq, &loginResult); err != nil {
+		return nil, err
+	}
+	if !loginResult.Data.Risky() {
+		return &loginResult, nil
+	}
+	if s.captchaSolver == nil {
+		return nil, models.ErrRiskControl
+	}
+
+	s.logger.Debug("触发风控，获取验证码")
+	challenge, err := s.captchaFetcher.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 获取验证码失败: %w", models.ErrRiskControl, err)
+	}
+	solution, err := s.captchaSolver.Solve(ctx, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 验证码求解失败: %w", models.ErrRiskControl, err)
+	}
+	loginReq.CaptchaType = solution.CaptchaType
+	loginReq.Challenge = solution.Challenge
+	loginReq.Validate = solution.Validate
+	loginReq.Seccode = solution.Seccode
+	loginReq.ImageToken = solution.ImageToken
+	loginReq.CaptchaCode = solution.CaptchaCode
+
+	loginResult = models.BaseResponse[models.SdkLoginResp]{}
+	if _, err = s.execWithRetry(ctx, &loginReq, &loginResult); err != nil {
+		return nil, err
+	}
+	if loginResult.Data.Risky() {
+		return nil, models.ErrRiskControl
+	}
+	return &loginResult, nil
 }
 
 // callApi 执行一般API
 func (s *session) callApi(
+	ctx context.Context,
 	request models.IRequest,
 	result models.IResponse,
 ) (*resty.Response, error) {
 	var resp *resty.Response
 	var err error
+
+	ctx, cancel := s.requestCtx(ctx)
+	defer cancel()
+
+	// 同一时间只有一个请求（或登录）在进行，登录因此也是单飞的
+	if err = s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
+	// 已过每日重置时间，主动重新登录
+	if s.logged && s.sessionExpired() {
+		s.logger.Debug("已过每日重置时间，重新登录")
+		s.logged = false
+		s.resumed = false
+	}
 	if !s.logged {
-		if err = s.login(); err != nil {
+		if err = s.login(ctx); err != nil {
 			return nil, err
 		}
 	}
-	resp, err = s.execReq(request, result)
-	if err != nil && err.(*models.ApiError).ApiCode == 3 {
+	resp, err = s.execWithRetry(ctx, request, result)
+	if errors.Is(err, models.ErrSessionExpired) {
 		s.logged = false
-		return nil, err
+		if !s.resumed {
+			return nil, err
+		}
+		// 恢复的会话已失效，透明地回退到完整登录流程并重试本次请求
+		s.resumed = false
+		s.logger.Debug("恢复的会话已失效，重新登录")
+		if err = s.getConfig(ctx); err != nil {
+			return nil, err
+		}
+		if err = s.login(ctx); err != nil {
+			return nil, err
+		}
+		resp, err = s.execWithRetry(ctx, request, result)
+		if errors.Is(err, models.ErrSessionExpired) {
+			s.logged = false
+			return nil, err
+		}
 	}
-	return resp, err
+	s.resumed = false
+	if err != nil {
+		return resp, s.toMaintenanceError(ctx, err)
+	}
+	return resp, nil
+}
+
+// sessionExpired 会话是否已过每日重置时间
+func (s *session) sessionExpired() bool {
+	expireTime := s.expireTime.Load()
+	return expireTime != 0 && time.Now().Unix() >= int64(expireTime)
 }
 
 func (s *session) Close() {
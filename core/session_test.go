@@ -0,0 +1,26 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"gopcr/config"
+	"gopcr/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	acc := SdkAccount{Uid: "u1", AccessKey: "key", Platform: "2", Channel: "1"}
+	_, err := NewClient(context.Background(), acc, WithBaseURL(srv.URL), WithConfig(config.NewBili()))
+	var apiErr *models.ApiError
+	if !errors.As(err, &apiErr) || apiErr.Operation != "execReq:HttpStatus" {
+		t.Fatalf("got %v, want HTTP status error", err)
+	}
+	if apiErr.HTTPStatus != http.StatusNotFound || apiErr.Unwrap() != nil {
+		t.Fatalf("got %#v", apiErr)
+	}
+}
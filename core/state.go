@@ -0,0 +1,71 @@
+package core
+
+import (
+	"cmp"
+	"context"
+)
+
+// SessionState 已登录会话的可序列化状态，用于在进程重启后继续请求链
+type SessionState struct {
+	ViewerId    uint64 `json:"viewer_id"`
+	RequestId   string `json:"request_id"`   // 当前REQUEST-ID头
+	SID         string `json:"sid"`          // 当前SID头（已计算）
+	ManifestVer string `json:"manifest_ver"` // MANIFEST-VER头
+	AppVer      string `json:"app_ver"`      // APP-VER头
+	Host        string `json:"host"`         // 当前使用的API根地址
+	ExpireTime  uint   `json:"expire_time"`  // 每日重置时间
+}
+
+// ExportState 导出当前会话状态，未登录时返回nil。
+// 会等待正在进行的请求完成，以保证导出的请求链是一致的
+func (c *Client) ExportState() *SessionState {
+	_ = c.acquire(context.Background())
+	defer c.release()
+
+	if !c.logged {
+		return nil
+	}
+	header := c.httpClient.Header
+	return &SessionState{
+		ViewerId:    c.viewerId,
+		RequestId:   header.Get("REQUEST-ID"),
+		SID:         header.Get("SID"),
+		ManifestVer: header.Get("MANIFEST-VER"),
+		AppVer:      cmp.Or(header.Get("APP-VER"), c.config.AppVer()),
+		Host:        c.httpClient.BaseURL,
+		ExpireTime:  uint(c.expireTime.Load()),
+	}
+}
+
+// ResumeClient 由导出的会话状态恢复客户端，不重新执行登录流程。
+// 服务器返回会话失效(3)时自动回退到完整登录；state为空或不完整时等同于 NewClient
+func ResumeClient(ctx context.Context, sdkAccount SdkAccount, state *SessionState, options ...SessionOption) (*Client, error) {
+	if state == nil || state.ViewerId == 0 || state.RequestId == "" {
+		return NewClient(ctx, sdkAccount, options...)
+	}
+
+	s := buildSession(sdkAccount, options...)
+	headers := map[string]string{
+		"REQUEST-ID": state.RequestId,
+		"SID":        state.SID,
+	}
+	if state.ManifestVer != "" {
+		headers["MANIFEST-VER"] = state.ManifestVer
+	}
+	// 保存的AppVer只作用于本客户端，且不回退到比配置更旧的版本
+	if state.AppVer != "" && compareAppVer(state.AppVer, s.config.AppVer()) > 0 {
+		headers["APP-VER"] = state.AppVer
+	}
+	s.httpClient.SetHeaders(headers)
+	// 继续使用导出时的地址，配置中的地址作为候选；WithHosts 指定的列表优先
+	if state.Host != "" && !s.hosts.fixed {
+		s.hosts.set([]string{state.Host, s.httpClient.BaseURL})
+		s.httpClient.SetBaseURL(s.hosts.get())
+	}
+
+	s.viewerId = state.ViewerId
+	s.expireTime.Store(uint64(state.ExpireTime))
+	s.logged = true
+	s.resumed = true
+	return &Client{session: s}, nil
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"gopcr/config"
+	"gopcr/mockserver"
+	"testing"
+)
+
+// exportTestState 登录并导出会话状态
+func exportTestState(t *testing.T, srv *mockserver.Server, uid string) *SessionState {
+	t.Helper()
+	c := newTestClient(t, srv, uid, WithConfig(config.NewBili()))
+	if _, err := c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	state := c.ExportState()
+	if state == nil {
+		t.Fatal("ExportState returned nil after login")
+	}
+	return state
+}
+
+func TestResumeClientRestoresHost(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	state := exportTestState(t, srv, "u1")
+	if state.Host != normalizeHost(srv.URL) {
+		t.Fatalf("exported host %q, want %q", state.Host, srv.URL)
+	}
+
+	// 不指定地址，恢复后应继续使用导出时的地址而不是配置中的默认地址
+	acc := SdkAccount{Uid: "u1", AccessKey: "key", Platform: "2", Channel: "1"}
+	c, err := ResumeClient(context.Background(), acc, state, WithConfig(config.NewBili()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err = c.HomeIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Calls(mockserver.EndpointSdkLogin); n != 1 {
+		t.Fatalf("sdk_login called %d times, want 1 (resume should not log in again)", n)
+	}
+}
+
+func TestResumeClientAppVerIsPerSession(t *testing.T) {
+	srv := mockserver.New()
+	defer srv.Close()
+	state := exportTestState(t, srv, "u1")
+	acc := SdkAccount{Uid: "u1", AccessKey: "key", Platform: "2", Channel: "1"}
+
+	shared := config.NewBili()
+	before := shared.AppVer()
+
+	// 比配置旧的版本被忽略
+	state.AppVer = "0.0.1"
+	old, err := ResumeClient(context.Background(), acc, state, WithBaseURL(srv.URL), WithConfig(shared))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	if got := old.ExportState().AppVer; got != before {
+		t.Fatalf("resumed AppVer %q, want %q", got, before)
+	}
+
+	// 较新的版本只作用于恢复的客户端
+	state.AppVer = "99.0.0"
+	newer, err := ResumeClient(context.Background(), acc, state, WithBaseURL(srv.URL), WithConfig(shared))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newer.Close()
+	if got := newer.ExportState().AppVer; got != "99.0.0" {
+		t.Fatalf("resumed AppVer %q, want 99.0.0", got)
+	}
+	if got := shared.AppVer(); got != before {
+		t.Fatalf("shared config AppVer changed to %q", got)
+	}
+}
+
+func TestCompareAppVer(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"8.10.0", "8.9.1", 1},
+		{"8.1.0", "8.1", 0},
+		{"0.0.1", "8.1.0", -1},
+	} {
+		if got := compareAppVer(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareAppVer(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
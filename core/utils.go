@@ -1,11 +1,15 @@
 package core
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"time"
+	"cmp"
+	"context"
+	"errors"
+	"gopcr/config"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // RandEvenNum 生成随机偶数，0 <= n <= 100000
@@ -16,42 +20,56 @@ import (
 //	return rand.New(source).Intn(50001) * 2
 //}
 
-func getNewAppVer() (string, error) {
-	const url = "https://line1-h5-pc-api.biligame.com/game/detail/content?game_base_id=102216"
+// appVerMu 串行化AppVer的获取，多个客户端同时遇到204时只请求一次
+var appVerMu sync.Mutex
 
-	client := http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to get app version: %w", err)
-	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			return
-		}
-	}(resp.Body)
+// discoverAppVer 获取新的AppVer。
+// 若共享配置中的AppVer已被其他客户端更新（与staleVer不同），则直接使用，不再请求
+func discoverAppVer(ctx context.Context, cfg *config.Config, provider AppVersionProvider, staleVer string) (string, error) {
+	appVerMu.Lock()
+	defer appVerMu.Unlock()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if ver := cfg.AppVer(); ver != staleVer {
+		return ver, nil
 	}
+	return provider.AppVersion(ctx, staleVer)
+}
 
-	body, err := io.ReadAll(resp.Body)
+// writeFileAtomic 先写临时文件再重命名，避免其他进程读到不完整的内容
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
-
-	var result struct {
-		Data struct {
-			AndroidVersion string `json:"android_version"`
-		} `json:"data"`
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
 	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("invalid JSON: %w", err)
+	if err != nil {
+		_ = os.Remove(tmp.Name())
 	}
+	return err
+}
 
-	if result.Data.AndroidVersion == "" {
-		return "", fmt.Errorf("android_version is empty")
+// compareAppVer 按数字逐段比较版本号，例如 8.10.0 > 8.9.1。a较新时返回正数
+func compareAppVer(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return cmp.Compare(x, y)
+		}
 	}
-
-	return result.Data.AndroidVersion, nil
+	return 0
 }
@@ -3,8 +3,10 @@ module gopcr
 go 1.24
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/go-resty/resty/v2 v2.16.5
 	github.com/ugorji/go/codec v1.2.12
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/net v0.40.0 // indirect
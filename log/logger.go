@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"time"
@@ -39,7 +40,7 @@ func SetLevel(l int) {
 }
 
 // SetOutput 设置日志输出位置
-func SetOutput(w *os.File) {
+func SetOutput(w io.Writer) {
 	logger.SetOutput(w)
 }
 
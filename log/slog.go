@@ -0,0 +1,268 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted 脱敏后的占位值
+const redacted = "[REDACTED]"
+
+// Default 返回写入全局日志（SetOutput、SetLevel）的 *slog.Logger，已脱敏。
+// 未指定Logger的客户端使用它，输出格式与 Debug/Info 等函数一致
+func Default() *slog.Logger {
+	return slog.New(Redact(&legacyHandler{}))
+}
+
+// NewJSON 创建以JSON格式写入w的 *slog.Logger，已脱敏。lvl为 LevelDebug 等日志级别
+func NewJSON(w io.Writer, lvl int) *slog.Logger {
+	return slog.New(Redact(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: SlogLevel(lvl)})))
+}
+
+// SlogLevel 将日志级别转换为 slog.Level
+func SlogLevel(lvl int) slog.Level {
+	switch lvl {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// Redact 包装h，对敏感属性脱敏：access_key、SID、REQUEST-ID，以及加密后的viewer_id（非纯数字的字符串）。
+// 属性名不区分大小写，忽略 "-" 和 "_"；http.Header 和 map[string]string 中的同名键同样脱敏
+func Redact(h slog.Handler) slog.Handler {
+	if _, ok := h.(*redactHandler); ok {
+		return h
+	}
+	return &redactHandler{next: h}
+}
+
+type redactHandler struct {
+	next slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(clean)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+// normalizeKey 统一属性名，例如 "Access-Key"、"accessKey" 都变为 "accesskey"
+func normalizeKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+// sensitive 判断属性值是否需要脱敏
+func sensitive(key, value string) bool {
+	if value == "" {
+		return false
+	}
+	switch normalizeKey(key) {
+	case "accesskey", "sid", "requestid":
+		return true
+	case "viewerid":
+		// 明文viewer_id为纯数字，加密后为Base64
+		return strings.TrimLeft(value, "0123456789") != ""
+	}
+	return false
+}
+
+// RedactHeaders 返回脱敏后的请求头，每个头只保留第一个值，用于写入请求记录
+func RedactHeaders(header http.Header) map[string]string {
+	clean := make(map[string]string, len(header))
+	for k := range header {
+		value := header.Get(k)
+		if sensitive(k, value) {
+			value = redacted
+		}
+		clean[k] = value
+	}
+	return clean
+}
+
+// RedactValue 返回v的脱敏副本，敏感键的规则同 Redact。
+// 递归处理 map[string]any、map[string]string 和 []any，用于写入请求记录的明文
+func RedactValue(v any) any {
+	return redactValue("", v)
+}
+
+func redactValue(key string, v any) any {
+	switch v := v.(type) {
+	case string:
+		if sensitive(key, v) {
+			return redacted
+		}
+	case map[string]any:
+		clean := make(map[string]any, len(v))
+		for k, value := range v {
+			clean[k] = redactValue(k, value)
+		}
+		return clean
+	case map[string]string:
+		clean := make(map[string]string, len(v))
+		for k, value := range v {
+			if sensitive(k, value) {
+				value = redacted
+			}
+			clean[k] = value
+		}
+		return clean
+	case []any:
+		clean := make([]any, len(v))
+		for i, value := range v {
+			clean[i] = redactValue(key, value)
+		}
+		return clean
+	}
+	return v
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		if sensitive(a.Key, v.String()) {
+			return slog.String(a.Key, redacted)
+		}
+	case slog.KindGroup:
+		attrs := v.Group()
+		clean := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			clean[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(clean...)}
+	case slog.KindAny:
+		switch m := v.Any().(type) {
+		case http.Header:
+			clean := m.Clone()
+			for k, values := range clean {
+				for i, value := range values {
+					if sensitive(k, value) {
+						values[i] = redacted
+					}
+				}
+			}
+			return slog.Any(a.Key, clean)
+		case map[string]string:
+			clean := make(map[string]string, len(m))
+			for k, value := range m {
+				if sensitive(k, value) {
+					value = redacted
+				}
+				clean[k] = value
+			}
+			return slog.Any(a.Key, clean)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// legacyHandler 以 "[时间][级别] 消息 key=value" 格式写入全局日志，遵循全局日志级别
+type legacyHandler struct {
+	prefix string // WithAttrs 添加的属性，已格式化
+	group  string // WithGroup 添加的属性名前缀
+}
+
+// legacyMu 保证同一条日志的属性不被其他日志打断
+var legacyMu sync.Mutex
+
+func (h *legacyHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= SlogLevel(level)
+}
+
+func (h *legacyHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	b.WriteString(h.prefix)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.group, a)
+		return true
+	})
+
+	lvl := LevelInfo
+	switch {
+	case r.Level >= slog.LevelError:
+		lvl = LevelError
+	case r.Level >= slog.LevelWarn:
+		lvl = LevelWarn
+	case r.Level < slog.LevelInfo:
+		lvl = LevelDebug
+	}
+	legacyMu.Lock()
+	defer legacyMu.Unlock()
+	logger.Print(formatPrefix(lvl) + b.String())
+	return nil
+}
+
+func (h *legacyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.prefix)
+	for _, a := range attrs {
+		appendAttr(&b, h.group, a)
+	}
+	return &legacyHandler{prefix: b.String(), group: h.group}
+}
+
+func (h *legacyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &legacyHandler{prefix: h.prefix, group: h.group + name + "."}
+}
+
+// appendAttr 以 " key=value" 格式追加属性，组内属性名带组名前缀
+func appendAttr(b *strings.Builder, group string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			appendAttr(b, group, ga)
+		}
+		return
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	var s string
+	switch v.Kind() {
+	case slog.KindTime:
+		s = v.Time().Format(time.DateTime)
+	default:
+		s = fmt.Sprint(v.Any())
+	}
+	if strings.ContainsAny(s, " =\"") {
+		s = fmt.Sprintf("%q", s)
+	}
+	fmt.Fprintf(b, " %s%s=%s", group, a.Key, s)
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	const (
+		accessKey = "secret-access-key"
+		sid       = "secret-sid"
+		requestId = "secret-request-id"
+		viewerId  = "ZW5jcnlwdGVkLXZpZXdlcg=="
+	)
+	var buf bytes.Buffer
+	logger := slog.New(Redact(slog.NewJSONHandler(&buf, nil))).With("access_key", accessKey)
+
+	header := http.Header{}
+	header.Set("SID", sid)
+	header.Set("REQUEST-ID", requestId)
+	header.Set("APP-VER", "8.1.0")
+	logger.Info("request",
+		"AccessKey", accessKey,
+		"viewer_id", viewerId,
+		slog.Group("session", "sid", sid, slog.Group("inner", "Access-Key", accessKey)),
+		"header", header,
+		"body", map[string]string{"access_key": accessKey, "viewer_id": viewerId, "uid": "42"},
+		"account", "u1",
+	)
+	logger.WithGroup("chain").With("SID", sid).Info("next", "viewerId", "1000000001")
+
+	out := buf.String()
+	for _, secret := range []string{accessKey, sid, requestId, viewerId} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("%q leaked into the log:\n%s", secret, out)
+		}
+	}
+	// 非敏感的属性和明文viewer_id保留
+	for _, keep := range []string{`"account":"u1"`, `"uid":"42"`, "8.1.0", `"viewerId":"1000000001"`} {
+		if !strings.Contains(out, keep) {
+			t.Fatalf("%s missing from the log:\n%s", keep, out)
+		}
+	}
+	if n := strings.Count(out, redacted); n < 10 {
+		t.Fatalf("got %d redacted values, want at least 10:\n%s", n, out)
+	}
+}
+
+func TestRedactIsIdempotent(t *testing.T) {
+	h := Redact(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	if Redact(h) != h {
+		t.Fatal("Redact wrapped an already redacting handler")
+	}
+}
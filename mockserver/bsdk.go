@@ -0,0 +1,169 @@
+package mockserver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 模拟B站游戏SDK的接口，可作为 bsdk.WithBaseURL 的本地替身
+const (
+	EndpointSdkRsa           = "api/client/rsa"
+	EndpointSdkPasswordLogin = "api/client/login"
+	EndpointSdkRenewal       = "api/client/session.renewal"
+)
+
+// B站SDK的错误码
+const (
+	sdkCodeCaptchaRequired = 200000
+	sdkCodeBadPassword     = 500002
+	sdkCodeBadToken        = 500003
+	sdkCodeBadRequest      = -400
+)
+
+// SdkUser B站SDK账号，登录成功后会同步注册为游戏账号
+type SdkUser struct {
+	Username string
+	Password string
+	Uid      string
+	// CaptchaRequired 为true时登录需要携带正确的验证码结果
+	CaptchaRequired bool
+}
+
+// sdkState 模拟SDK的状态
+type sdkState struct {
+	keyOnce sync.Once
+	key     *rsa.PrivateKey
+	pubPEM  string
+
+	users  map[string]*SdkUser // username -> user
+	hashes map[string]bool     // 已发放且未使用的密码hash
+	tokens map[string]string   // access_key -> uid
+}
+
+// AddSdkUser 注册B站SDK账号
+func (s *Server) AddSdkUser(user SdkUser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := user
+	s.sdk.users[u.Username] = &u
+}
+
+// rsaKey 懒加载RSA密钥
+func (s *Server) rsaKey() (*rsa.PrivateKey, string) {
+	s.sdk.keyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		if err != nil {
+			panic(err)
+		}
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			panic(err)
+		}
+		s.sdk.key = key
+		s.sdk.pubPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	})
+	return s.sdk.key, s.sdk.pubPEM
+}
+
+// handleSdk 处理SDK表单请求，要求携带签名
+func (s *Server) handleSdk(endpoint string, fn func(r *http.Request) map[string]any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var result map[string]any
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("sign") == "" {
+			s.mu.Lock()
+			s.calls[endpoint]++
+			s.recordError(endpoint, "缺少签名")
+			s.mu.Unlock()
+			result = map[string]any{"code": sdkCodeBadRequest, "message": "sign required"}
+		} else {
+			// 生成密钥较慢，在持有锁之前完成
+			s.rsaKey()
+			s.mu.Lock()
+			s.calls[endpoint]++
+			result = fn(r)
+			s.mu.Unlock()
+		}
+		if _, ok := result["code"]; !ok {
+			result["code"] = 0
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	}
+}
+
+func (s *Server) sdkRsa(*http.Request) map[string]any {
+	_, pub := s.rsaKey()
+	hash := randomHex(16)
+	s.sdk.hashes[hash] = true
+	return map[string]any{"hash": hash, "rsa_key": pub}
+}
+
+func (s *Server) sdkLoginPassword(r *http.Request) map[string]any {
+	user, ok := s.sdk.users[r.PostForm.Get("user_id")]
+	if !ok {
+		return map[string]any{"code": sdkCodeBadPassword, "message": "用户名或密码错误"}
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(r.PostForm.Get("pwd"))
+	if err != nil {
+		return map[string]any{"code": sdkCodeBadRequest, "message": "invalid pwd"}
+	}
+	key, _ := s.rsaKey()
+	plain, err := rsa.DecryptPKCS1v15(rand.Reader, key, encrypted)
+	if err != nil || len(plain) < 16 || !s.sdk.hashes[string(plain[:16])] {
+		return map[string]any{"code": sdkCodeBadRequest, "message": "invalid hash"}
+	}
+	delete(s.sdk.hashes, string(plain[:16]))
+	if string(plain[16:]) != user.Password {
+		return map[string]any{"code": sdkCodeBadPassword, "message": "用户名或密码错误"}
+	}
+
+	if user.CaptchaRequired {
+		challenge := r.PostForm.Get("challenge")
+		if !s.challenges[challenge] || r.PostForm.Get("validate") != SolveChallenge(challenge) ||
+			!strings.HasPrefix(r.PostForm.Get("seccode"), r.PostForm.Get("validate")) {
+			return map[string]any{"code": sdkCodeCaptchaRequired, "message": "需要验证码"}
+		}
+		delete(s.challenges, challenge)
+	}
+	return s.issueToken(user.Uid, user.Username)
+}
+
+func (s *Server) sdkRenewal(r *http.Request) map[string]any {
+	accessKey := r.PostForm.Get("access_key")
+	uid, ok := s.sdk.tokens[accessKey]
+	if !ok || uid != r.PostForm.Get("uid") {
+		return map[string]any{"code": sdkCodeBadToken, "message": "access_key无效"}
+	}
+	delete(s.sdk.tokens, accessKey)
+	return s.issueToken(uid, "")
+}
+
+// issueToken 发放新的access_key，并同步到游戏账号。调用时持有锁
+func (s *Server) issueToken(uid, uname string) map[string]any {
+	accessKey := randomHex(32)
+	s.sdk.tokens[accessKey] = uid
+
+	acc, ok := s.accounts[uid]
+	if !ok {
+		acc = &Account{Uid: uid, ViewerId: s.allocViewerId()}
+		s.accounts[uid] = acc
+	}
+	acc.AccessKey = accessKey
+
+	return map[string]any{
+		"uid":        uid,
+		"access_key": accessKey,
+		"uname":      uname,
+		"expires":    time.Now().Add(30 * 24 * time.Hour).Unix(),
+	}
+}
@@ -0,0 +1,50 @@
+package mockserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"gopcr/pcrcrypto"
+	"strconv"
+)
+
+// 与客户端相同的加密方案，见 pcrcrypto 包
+// 请求体为原始字节，响应体为Base64编码
+
+var codec = pcrcrypto.New()
+
+// calcSID 计算客户端应携带的SID头
+func calcSID(sid string) string {
+	return pcrcrypto.CalcSID(sid)
+}
+
+// randomHex 生成n位随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, (n+1)/2)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)[:n]
+}
+
+// decryptBody 解密请求体（原始字节）
+func decryptBody(data []byte) ([]byte, error) {
+	return pcrcrypto.DecryptInPlace(data)
+}
+
+// encryptBody 加密响应数据并Base64编码
+func encryptBody(v any) ([]byte, error) {
+	return codec.EncryptResponse(v)
+}
+
+// decodeViewerId 解密请求中的viewer_id字段
+func decodeViewerId(encoded string) (string, error) {
+	id, err := codec.DecryptViewerId(encoded)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(id, 10), nil
+}
+
+func decodeMsgpack(data []byte, v any) error {
+	return codec.Unmarshal(data, v)
+}
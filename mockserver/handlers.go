@@ -0,0 +1,267 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"gopcr/models"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// exchange 一次请求的上下文
+type exchange struct {
+	header   http.Header
+	body     map[string]any
+	viewerId uint64
+	viewer   *viewerState // 已登录玩家，sdk_login 时由处理函数设置
+}
+
+// handlerFunc 处理已解密的请求，返回响应data和结果码。调用时持有锁
+type handlerFunc func(ex *exchange) (map[string]any, int)
+
+// handleEncrypted 处理加密接口：解密请求、校验请求链、加密响应
+func (s *Server) handleEncrypted(endpoint string, fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.calls[endpoint]++
+
+		ex := &exchange{header: r.Header}
+		plain, err := decryptBody(raw)
+		if err != nil {
+			s.recordError(endpoint, "请求解密失败: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = decodeMsgpack(plain, &ex.body); err != nil {
+			s.recordError(endpoint, "msgpack解码失败: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		encodedViewerId, _ := ex.body["viewer_id"].(string)
+		viewerId, err := decodeViewerId(encodedViewerId)
+		if err != nil {
+			s.recordError(endpoint, "viewer_id解密失败: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ex.viewerId, err = strconv.ParseUint(viewerId, 10, 64); err != nil {
+			s.recordError(endpoint, "无效的viewer_id: %q", viewerId)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data, code := s.dispatch(endpoint, ex, fn)
+
+		headers := map[string]any{
+			"viewer_id":   ex.viewerId,
+			"result_code": code,
+		}
+		// 仅在成功时推进请求链
+		if code == ResultCodeSuccess && ex.viewer != nil {
+			ex.viewer.requestId = randomHex(32)
+			ex.viewer.sid = randomHex(32)
+			headers["request_id"] = ex.viewer.requestId
+			headers["sid"] = ex.viewer.sid
+		}
+		if data == nil {
+			data = map[string]any{}
+		}
+
+		body, err := encryptBody(map[string]any{"data_headers": headers, "data": data})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-msgpack")
+		_, _ = w.Write(body)
+	}
+}
+
+// dispatch 处理预设结果码、维护状态和请求链校验，然后调用具体接口。调用时持有锁
+func (s *Server) dispatch(endpoint string, ex *exchange, fn handlerFunc) (map[string]any, int) {
+	if code, ok := s.popScript(endpoint); ok {
+		if code == ResultCodeSessionExpired {
+			delete(s.viewers, ex.viewerId)
+		}
+		return nil, code
+	}
+	if s.maintenance {
+		return s.maintenanceData(), ResultCodeMaintenance
+	}
+
+	if endpoint != EndpointSdkLogin {
+		st, ok := s.viewers[ex.viewerId]
+		if !ok {
+			// 会话已失效，属于正常情况
+			return nil, ResultCodeSessionExpired
+		}
+		if got := ex.header.Get("REQUEST-ID"); got != st.requestId {
+			s.recordError(endpoint, "REQUEST-ID不匹配: 期望 %q, 实际 %q", st.requestId, got)
+			return nil, ResultCodeSessionExpired
+		}
+		if got := ex.header.Get("SID"); got != calcSID(st.sid) {
+			s.recordError(endpoint, "SID不匹配: 期望 %q, 实际 %q", calcSID(st.sid), got)
+			return nil, ResultCodeSessionExpired
+		}
+		ex.viewer = st
+	}
+	return fn(ex)
+}
+
+// handlePlain 处理不加密的JSON接口
+func (s *Server) handlePlain(endpoint string, fn func() (map[string]any, int)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		s.mu.Lock()
+		s.calls[endpoint]++
+		data, code := fn()
+		if scripted, ok := s.popScript(endpoint); ok {
+			data, code = nil, scripted
+		}
+		s.mu.Unlock()
+
+		if data == nil {
+			data = map[string]any{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data_headers": map[string]any{"result_code": code},
+			"data":         data,
+		})
+	}
+}
+
+func (s *Server) maintenanceData() map[string]any {
+	data := map[string]any{
+		"maintenance_message":   s.maintenanceMessage,
+		"manifest_ver":          s.manifestVer,
+		"required_manifest_ver": s.manifestVer,
+		"required_app_ver":      s.appVer,
+	}
+	if !s.maintenanceStart.IsZero() {
+		data["start_time"] = s.maintenanceStart.Unix()
+	}
+	if !s.maintenanceEnd.IsZero() {
+		data["end_time"] = s.maintenanceEnd.Unix()
+	}
+	return data
+}
+
+func (s *Server) sourceIniIndex() (map[string]any, int) {
+	if len(s.servers) > 0 {
+		return map[string]any{"server": s.servers}, ResultCodeSuccess
+	}
+	return map[string]any{"server": []string{s.URL}}, ResultCodeSuccess
+}
+
+func (s *Server) maintenanceStatus() (map[string]any, int) {
+	if s.maintenance {
+		return s.maintenanceData(), ResultCodeMaintenance
+	}
+	return map[string]any{
+		"manifest_ver":          s.manifestVer,
+		"required_manifest_ver": s.manifestVer,
+		"required_app_ver":      s.appVer,
+	}, ResultCodeSuccess
+}
+
+func (s *Server) sdkLogin(ex *exchange) (map[string]any, int) {
+	uid, _ := ex.body["uid"].(string)
+	accessKey, _ := ex.body["access_key"].(string)
+
+	acc, ok := s.accounts[uid]
+	if !ok {
+		acc = &Account{Uid: uid, ViewerId: s.allocViewerId()}
+		s.accounts[uid] = acc
+	}
+	if acc.AccessKey != "" && acc.AccessKey != accessKey {
+		return nil, ResultCodeSessionExpired
+	}
+	if acc.Risky {
+		challenge, _ := ex.body["challenge"].(string)
+		validate, _ := ex.body["validate"].(string)
+		if !s.challenges[challenge] || validate != SolveChallenge(challenge) {
+			return map[string]any{"is_risk": true}, ResultCodeSuccess
+		}
+		delete(s.challenges, challenge)
+	}
+
+	// 重新登录时开始新的请求链
+	ex.viewerId = acc.ViewerId
+	ex.viewer = &viewerState{account: acc}
+	s.viewers[acc.ViewerId] = ex.viewer
+	return map[string]any{"is_risk": false}, ResultCodeSuccess
+}
+
+func (s *Server) gameStart(ex *exchange) (map[string]any, int) {
+	if ex.header.Get("APP-VER") != s.appVer {
+		return nil, ResultCodeVersionUpdated
+	}
+	acc := ex.viewer.account
+	return map[string]any{
+		"now_tutorial":   !acc.TutorialUnfinished,
+		"now_name":       acc.Name,
+		"now_team_level": acc.TeamLevel,
+	}, ResultCodeSuccess
+}
+
+func (s *Server) loadIndex(*exchange) (map[string]any, int) {
+	return map[string]any{"daily_reset_time": s.dailyResetTime}, ResultCodeSuccess
+}
+
+func (s *Server) homeIndex(*exchange) (map[string]any, int) {
+	return map[string]any{"daily_reset_time": s.dailyResetTime}, ResultCodeSuccess
+}
+
+// appVersion 模拟biligame游戏详情接口
+func (s *Server) appVersion(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	s.calls[EndpointAppVersion]++
+	ver := s.appVer
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"data": map[string]any{"android_version": ver},
+	})
+}
+
+// startCaptcha 模拟B站SDK的极验挑战接口
+func (s *Server) startCaptcha(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	s.calls[EndpointCaptcha]++
+	challenge := randomHex(32)
+	s.challenges[challenge] = true
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"code":       0,
+		"gt":         "mock-gt",
+		"challenge":  challenge,
+		"gt_user_id": "mock-user",
+	})
+}
+
+// zeroResponse 返回响应data类型零值的处理函数
+func zeroResponse(endpoint models.Endpoint) handlerFunc {
+	return func(*exchange) (map[string]any, int) {
+		raw, err := json.Marshal(reflect.New(endpoint.Response).Interface())
+		if err != nil {
+			return nil, ResultCodeSuccess
+		}
+		var data map[string]any
+		_ = json.Unmarshal(raw, &data)
+		return data, ResultCodeSuccess
+	}
+}
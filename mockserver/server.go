@@ -0,0 +1,312 @@
+// Package mockserver 提供一个进程内的模拟游戏服务器（基于httptest），
+// 使用与真实服务器相同的加密协议，用于离线测试 core.Client
+package mockserver
+
+import (
+	"fmt"
+	"gopcr/models"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// 模拟服务器使用的结果码
+const (
+	ResultCodeSuccess        = models.ResultCodeSuccess
+	ResultCodeSessionExpired = models.ResultCodeSessionExpired
+	ResultCodeMaintenance    = models.ResultCodeMaintenance
+	ResultCodeVersionUpdated = models.ResultCodeVersionUpdated
+)
+
+// 模拟服务器实现的接口
+const (
+	EndpointSourceIniIndex    = "source_ini/index"
+	EndpointMaintenanceStatus = "source_ini/get_maintenance_status"
+	EndpointSdkLogin          = "tool/sdk_login"
+	EndpointGameStart         = "check/game_start"
+	EndpointLoadIndex         = "load/index"
+	EndpointHomeIndex         = "home/index"
+	// EndpointAppVersion 模拟biligame的游戏详情接口，用于更新AppVer
+	EndpointAppVersion = "game/detail/content"
+	// EndpointCaptcha 模拟B站SDK获取极验挑战的接口
+	EndpointCaptcha = "api/client/start_captcha"
+)
+
+// Account 模拟服务器上的账号
+type Account struct {
+	Uid       string
+	AccessKey string // 为空时不校验
+	ViewerId  uint64 // 为0时自动分配
+	// TutorialUnfinished 为true时 game_start 返回未完成教程
+	TutorialUnfinished bool
+	TeamLevel          int
+	Name               string
+	// Risky 为true时 sdk_login 返回is_risk，需要携带正确的验证码结果重新登录
+	Risky bool
+}
+
+// viewerState 已登录玩家的请求链状态
+type viewerState struct {
+	account   *Account
+	requestId string // 下一次请求应携带的REQUEST-ID
+	sid       string // 下一次请求应携带的SID（计算前）
+}
+
+// Server 模拟游戏服务器
+type Server struct {
+	// URL 服务器根地址，可直接作为 core.WithBaseURL 的参数
+	URL string
+
+	ts *httptest.Server
+
+	mu                 sync.Mutex
+	appVer             string
+	manifestVer        string
+	maintenance        bool
+	maintenanceMessage string
+	maintenanceStart   time.Time
+	maintenanceEnd     time.Time
+	dailyResetTime     int64
+	accounts           map[string]*Account
+	viewers            map[uint64]*viewerState
+	scripts            map[string][]int
+	calls              map[string]int
+	errs               []error
+	nextViewerId       uint64
+	challenges         map[string]bool // 已发放且未使用的验证码挑战
+	servers            []string        // source_ini/index 返回的服务器列表，为空时返回自身地址
+	sdk                sdkState
+}
+
+// implemented 模拟服务器具体实现的游戏接口
+var implemented = map[string]bool{
+	EndpointSourceIniIndex:    true,
+	EndpointMaintenanceStatus: true,
+	EndpointSdkLogin:          true,
+	EndpointGameStart:         true,
+	EndpointLoadIndex:         true,
+	EndpointHomeIndex:         true,
+}
+
+// Option 定义模拟服务器选项
+type Option func(*Server)
+
+// WithAppVer 服务器要求的客户端版本，APP-VER不一致时 game_start 返回204
+func WithAppVer(ver string) Option {
+	return func(s *Server) {
+		s.appVer = ver
+	}
+}
+
+// WithManifestVer 设置 get_maintenance_status 返回的manifest版本
+func WithManifestVer(ver string) Option {
+	return func(s *Server) {
+		s.manifestVer = ver
+	}
+}
+
+// WithDailyResetTime 设置 load/index 返回的每日重置时间
+func WithDailyResetTime(t time.Time) Option {
+	return func(s *Server) {
+		s.dailyResetTime = t.Unix()
+	}
+}
+
+// WithServerList 设置 source_ini/index 返回的服务器列表，默认只包含服务器自身的地址
+func WithServerList(servers ...string) Option {
+	return func(s *Server) {
+		s.servers = servers
+	}
+}
+
+// New 创建并启动一个模拟服务器，使用完毕后需调用 Close
+func New(options ...Option) *Server {
+	s := &Server{
+		appVer:         "8.1.0",
+		manifestVer:    "10002200",
+		dailyResetTime: nextDailyReset(time.Now()).Unix(),
+		accounts:       make(map[string]*Account),
+		viewers:        make(map[uint64]*viewerState),
+		scripts:        make(map[string][]int),
+		calls:          make(map[string]int),
+		nextViewerId:   1000000000,
+		challenges:     make(map[string]bool),
+		sdk: sdkState{
+			users:  make(map[string]*SdkUser),
+			hashes: make(map[string]bool),
+			tokens: make(map[string]string),
+		},
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+EndpointSourceIniIndex, s.handlePlain(EndpointSourceIniIndex, s.sourceIniIndex))
+	mux.HandleFunc("/"+EndpointMaintenanceStatus, s.handlePlain(EndpointMaintenanceStatus, s.maintenanceStatus))
+	mux.HandleFunc("/"+EndpointSdkLogin, s.handleEncrypted(EndpointSdkLogin, s.sdkLogin))
+	mux.HandleFunc("/"+EndpointGameStart, s.handleEncrypted(EndpointGameStart, s.gameStart))
+	mux.HandleFunc("/"+EndpointLoadIndex, s.handleEncrypted(EndpointLoadIndex, s.loadIndex))
+	mux.HandleFunc("/"+EndpointHomeIndex, s.handleEncrypted(EndpointHomeIndex, s.homeIndex))
+	mux.HandleFunc("/"+EndpointAppVersion, s.appVersion)
+	mux.HandleFunc("/"+EndpointCaptcha, s.startCaptcha)
+	mux.HandleFunc("/"+EndpointSdkRsa, s.handleSdk(EndpointSdkRsa, s.sdkRsa))
+	mux.HandleFunc("/"+EndpointSdkPasswordLogin, s.handleSdk(EndpointSdkPasswordLogin, s.sdkLoginPassword))
+	mux.HandleFunc("/"+EndpointSdkRenewal, s.handleSdk(EndpointSdkRenewal, s.sdkRenewal))
+	// 其他已注册的加密接口返回零值响应，用于测试新增的接口
+	for _, endpoint := range models.Endpoints() {
+		if !endpoint.Encrypted || implemented[endpoint.Path] {
+			continue
+		}
+		mux.HandleFunc("/"+endpoint.Path, s.handleEncrypted(endpoint.Path, zeroResponse(endpoint)))
+	}
+
+	s.ts = httptest.NewServer(mux)
+	s.URL = s.ts.URL + "/"
+	return s
+}
+
+// Close 关闭服务器
+func (s *Server) Close() {
+	s.ts.Close()
+}
+
+// AppVersionURL 返回模拟的AppVer查询地址
+func (s *Server) AppVersionURL() string {
+	return s.URL + EndpointAppVersion
+}
+
+// CaptchaURL 返回模拟的验证码挑战地址
+func (s *Server) CaptchaURL() string {
+	return s.URL + EndpointCaptcha
+}
+
+// SolveChallenge 返回模拟服务器认可的validate，用于测试用的验证码求解器
+func SolveChallenge(challenge string) string {
+	return "mock-validate-" + challenge
+}
+
+// AddAccount 注册账号。未注册的uid在登录时会被自动注册
+func (s *Server) AddAccount(account Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc := account
+	if acc.ViewerId == 0 {
+		acc.ViewerId = s.allocViewerId()
+	}
+	s.accounts[acc.Uid] = &acc
+}
+
+// ViewerId 返回uid对应的viewer_id，账号不存在时返回0
+func (s *Server) ViewerId(uid string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if acc, ok := s.accounts[uid]; ok {
+		return acc.ViewerId
+	}
+	return 0
+}
+
+// SetAppVer 修改服务器要求的客户端版本
+func (s *Server) SetAppVer(ver string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appVer = ver
+}
+
+// SetDailyResetTime 修改 load/index 和 home/index 返回的每日重置时间
+func (s *Server) SetDailyResetTime(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dailyResetTime = t.Unix()
+}
+
+// SetMaintenance 开启维护，维护期间所有接口返回101
+func (s *Server) SetMaintenance(message string) {
+	s.SetMaintenanceWindow(message, time.Now(), time.Time{})
+}
+
+// SetMaintenanceWindow 开启维护并设置维护时间，end为零值表示结束时间未知。
+// 维护不会在end时自动结束，需调用 ClearMaintenance
+func (s *Server) SetMaintenanceWindow(message string, start, end time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maintenance = true
+	s.maintenanceMessage = message
+	s.maintenanceStart = start
+	s.maintenanceEnd = end
+}
+
+// ClearMaintenance 结束维护
+func (s *Server) ClearMaintenance() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maintenance = false
+	s.maintenanceMessage = ""
+	s.maintenanceStart = time.Time{}
+	s.maintenanceEnd = time.Time{}
+}
+
+// ScriptResultCodes 预设接口接下来若干次请求返回的结果码（不做正常处理）。
+// 返回3时该玩家的会话同时失效
+func (s *Server) ScriptResultCodes(endpoint string, codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[endpoint] = append(s.scripts[endpoint], codes...)
+}
+
+// ExpireSessions 使所有已登录玩家的会话失效
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.viewers)
+}
+
+// Calls 返回接口被请求的次数
+func (s *Server) Calls(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[endpoint]
+}
+
+// Errors 返回服务器检测到的协议错误（解密失败、SID/REQUEST-ID链断裂等）
+func (s *Server) Errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.errs...)
+}
+
+// allocViewerId 分配viewer_id，调用方需持有锁
+func (s *Server) allocViewerId() uint64 {
+	s.nextViewerId++
+	return s.nextViewerId
+}
+
+// popScript 取出预设的结果码，调用方需持有锁
+func (s *Server) popScript(endpoint string) (int, bool) {
+	codes := s.scripts[endpoint]
+	if len(codes) == 0 {
+		return 0, false
+	}
+	s.scripts[endpoint] = codes[1:]
+	return codes[0], true
+}
+
+// recordError 记录协议错误，调用方需持有锁
+func (s *Server) recordError(endpoint string, format string, v ...any) {
+	s.errs = append(s.errs, fmt.Errorf("%s: %s", endpoint, fmt.Sprintf(format, v...)))
+}
+
+// nextDailyReset 返回下一个5:00（UTC+8）
+func nextDailyReset(now time.Time) time.Time {
+	loc := time.FixedZone("CST", 8*60*60)
+	now = now.In(loc)
+	reset := time.Date(now.Year(), now.Month(), now.Day(), 5, 0, 0, 0, loc)
+	if !reset.After(now) {
+		reset = reset.AddDate(0, 0, 1)
+	}
+	return reset
+}
@@ -29,6 +29,13 @@ type IResponse interface {
 type RespData interface {
 }
 
+// PayloadRequest 可选接口，请求体不是请求本身时实现，例如 RawRequest
+type PayloadRequest interface {
+	IRequest
+	// Payload 返回实际序列化的请求体
+	Payload() any
+}
+
 func parseModelUrl(path string) (*url.URL, error) {
 	//u, err := url.Parse("https://" + config.GetInstance().GetOptVal(config.PcrApiHost).(string) + path)
 	// 改由client处理api root
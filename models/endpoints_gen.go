@@ -0,0 +1,46 @@
+// Code generated by gopcr-gen. DO NOT EDIT.
+
+package models
+
+import "net/url"
+
+// ProfileUserInfo 玩家的公开信息
+type ProfileUserInfo struct {
+	ViewerId       uint64 `json:"viewer_id"`
+	UserName       string `json:"user_name"`
+	UserComment    string `json:"user_comment"`
+	TeamLevel      int    `json:"team_level"`
+	UnitNum        int    `json:"unit_num"`
+	TotalPower     int    `json:"total_power"`
+	ArenaRank      int    `json:"arena_rank"`       // 战斗竞技场排名
+	GrandArenaRank int    `json:"grand_arena_rank"` // 公主竞技场排名
+	LastLoginTime  int64  `json:"last_login_time"`
+}
+
+// ProfileGetProfile 查询玩家信息
+const profileGetProfileReqPath = "profile/get_profile"
+
+type ProfileGetProfileReq struct {
+	BaseRequest
+
+	TargetViewerId uint64 `json:"target_viewer_id"`
+}
+
+func NewProfileGetProfileReq() ProfileGetProfileReq {
+	req := ProfileGetProfileReq{
+		BaseRequest: NewBaseRequest(),
+	}
+	return req
+}
+
+func (r ProfileGetProfileReq) GetUrl() (*url.URL, error) {
+	return parseModelUrl(profileGetProfileReqPath)
+}
+
+type ProfileGetProfileResp struct {
+	UserInfo ProfileUserInfo `json:"user_info"`
+}
+
+func init() {
+	RegisterEndpoint[ProfileGetProfileResp](func() IRequest { r := NewProfileGetProfileReq(); return &r })
+}
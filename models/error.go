@@ -1,6 +1,21 @@
 package models
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 哨兵错误，可以通过 errors.Is 判断
+var (
+	ErrSessionExpired      = errors.New("会话已失效")    // 结果码3
+	ErrVersionUpdated      = errors.New("客户端版本已更新") // 结果码204
+	ErrMaintenance         = errors.New("服务器维护中")   // 结果码101
+	ErrTutorialNotFinished = errors.New("账号还没过教程")
+	ErrRiskControl         = errors.New("账号触发风控") // sdk_login返回is_risk
+	ErrLoginFailed         = errors.New("登录失败")
+)
 
 // ApiError 是一个自定义的错误类型，用于包含更多关于API调用失败的信息
 type ApiError struct {
@@ -18,8 +33,12 @@ func (e *ApiError) Error() string {
 		// 仅当HTTPStatus本身表示错误，或者没有业务错误码时强调HTTPStatus
 		errMsg += fmt.Sprintf(" (HTTP status: %d)", e.HTTPStatus)
 	}
-	if e.ApiCode != 0 && e.ApiCode != 1 { // 假设1是成功的业务码
-		errMsg += fmt.Sprintf(" (Api code: %d)", e.ApiCode)
+	if e.ApiCode != 0 && e.ApiCode != ResultCodeSuccess {
+		if info, ok := LookupResultCode(e.ApiCode); ok {
+			errMsg += fmt.Sprintf(" (Api code: %d %s)", e.ApiCode, info.Zh)
+		} else {
+			errMsg += fmt.Sprintf(" (Api code: %d)", e.ApiCode)
+		}
 	}
 	if e.Err != nil {
 		errMsg += fmt.Sprintf(" (caused by: %v)", e.Err)
@@ -31,3 +50,65 @@ func (e *ApiError) Error() string {
 func (e *ApiError) Unwrap() error {
 	return e.Err
 }
+
+// Is 根据结果码匹配对应的哨兵错误，例如结果码3匹配 ErrSessionExpired
+func (e *ApiError) Is(target error) bool {
+	if e.ApiCode == 0 {
+		return false
+	}
+	info, ok := LookupResultCode(e.ApiCode)
+	return ok && info.Err != nil && info.Err == target
+}
+
+// LoginError 登录流程多次尝试均失败，Attempts为每次尝试的错误。
+// 匹配 ErrLoginFailed，且可以通过 errors.Is/As 检查任意一次尝试的错误
+type LoginError struct {
+	Uid      string
+	Attempts []error
+}
+
+func (e *LoginError) Error() string {
+	causes := make([]string, 0, len(e.Attempts))
+	for i, err := range e.Attempts {
+		causes = append(causes, fmt.Sprintf("#%d: %v", i+1, err))
+	}
+	return fmt.Sprintf("%s %s (%d attempts: %s)", e.Uid, ErrLoginFailed, len(e.Attempts), strings.Join(causes, "; "))
+}
+
+func (e *LoginError) Unwrap() []error {
+	return e.Attempts
+}
+
+func (e *LoginError) Is(target error) bool {
+	return target == ErrLoginFailed
+}
+
+// MaintenanceError 服务器维护中，包含 get_maintenance_status 返回的维护信息。
+// 匹配 ErrMaintenance
+type MaintenanceError struct {
+	Message             string    // 维护公告
+	StartTime           time.Time // 维护开始时间，未知时为零值
+	EndTime             time.Time // 预计结束时间，未知时为零值
+	RequiredManifestVer string
+	RequiredAppVer      string
+	Err                 error // 原始错误
+}
+
+func (e *MaintenanceError) Error() string {
+	msg := ErrMaintenance.Error()
+	if !e.EndTime.IsZero() {
+		msg += fmt.Sprintf("，预计%s结束", e.EndTime.Format(time.DateTime))
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *MaintenanceError) Unwrap() error {
+	return e.Err
+}
+
+func (e *MaintenanceError) Is(target error) bool {
+	return target == ErrMaintenance
+}
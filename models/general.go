@@ -39,6 +39,30 @@ type SourceIniGetMaintenanceStatusReq struct {
 type SourceIniGetMaintenanceStatusResp struct {
 	ManifestVer         string `json:"manifest_ver"`
 	RequiredManifestVer string `json:"required_manifest_ver"`
+	RequiredResVer      string `json:"required_res_ver"`
+	RequiredAppVer      string `json:"required_app_ver"`
+
+	// 以下字段仅在维护中（结果码101）时返回
+	MaintenanceMessage string `json:"maintenance_message"`
+	StartTime          int64  `json:"start_time"` // 维护开始时间，Unix秒
+	EndTime            int64  `json:"end_time"`   // 预计结束时间，Unix秒，未知时为0
+}
+
+// MaintenanceError 由维护状态生成 MaintenanceError，cause为原始错误
+func (r SourceIniGetMaintenanceStatusResp) MaintenanceError(cause error) *MaintenanceError {
+	e := &MaintenanceError{
+		Message:             r.MaintenanceMessage,
+		RequiredManifestVer: r.RequiredManifestVer,
+		RequiredAppVer:      r.RequiredAppVer,
+		Err:                 cause,
+	}
+	if r.StartTime > 0 {
+		e.StartTime = time.Unix(r.StartTime, 0)
+	}
+	if r.EndTime > 0 {
+		e.EndTime = time.Unix(r.EndTime, 0)
+	}
+	return e
 }
 
 func NewSourceIniGetMaintenanceStatusReq() SourceIniGetMaintenanceStatusReq {
@@ -85,6 +109,11 @@ type SdkLoginResp struct {
 	IsRisk *bool `json:"is_risk"`
 }
 
+// Risky 是否触发风控，需要验证码
+func (s SdkLoginResp) Risky() bool {
+	return s.IsRisk != nil && *s.IsRisk
+}
+
 // GameStart
 const gameStartReqPath = "check/game_start"
 
@@ -168,3 +197,12 @@ func (l HomeIndexReq) GetUrl() (*url.URL, error) {
 type HomeIndexResp struct {
 	DailyResetTime uint `json:"daily_reset_time"`
 }
+
+func init() {
+	RegisterEndpoint[SourceIniIndexResp](func() IRequest { r := NewSourceIniIndexReq(); return &r })
+	RegisterEndpoint[SourceIniGetMaintenanceStatusResp](func() IRequest { r := NewSourceIniGetMaintenanceStatusReq(); return &r })
+	RegisterEndpoint[SdkLoginResp](func() IRequest { r := NewSdkLoginReq(); return &r })
+	RegisterEndpoint[GameStartResp](func() IRequest { r := NewGameStartReq(); return &r })
+	RegisterEndpoint[LoadIndexResp](func() IRequest { r := NewLoadIndexReq(); return &r })
+	RegisterEndpoint[HomeIndexResp](func() IRequest { r := NewHomeIndexReq(); return &r })
+}
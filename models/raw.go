@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// RawRequest 未建模接口的请求，请求体为任意map。
+// 用于在模型生成之前试用新接口，参见 core.Client.CallRaw
+type RawRequest struct {
+	path      string
+	isEncrypt bool
+	body      map[string]any
+}
+
+// NewRawRequest 创建原始请求。已注册的接口使用注册时的加密方式，其他接口默认加密。
+// body会被复制，viewer_id由客户端填写
+func NewRawRequest(path string, body map[string]any) *RawRequest {
+	path = strings.TrimPrefix(path, "/")
+	req := &RawRequest{
+		path:      path,
+		isEncrypt: true,
+		body:      make(map[string]any, len(body)+1),
+	}
+	if endpoint, ok := LookupEndpoint(path); ok {
+		req.isEncrypt = endpoint.Encrypted
+	}
+	for k, v := range body {
+		req.body[k] = v
+	}
+	return req
+}
+
+func (r *RawRequest) SetViewerId(viewerId string) {
+	r.body["viewer_id"] = viewerId
+}
+
+func (r *RawRequest) IsEncrypt() bool {
+	return r.isEncrypt
+}
+
+func (r *RawRequest) GetMethod() string {
+	return "POST"
+}
+
+func (r *RawRequest) GetUrl() (*url.URL, error) {
+	if r.path == "" {
+		return nil, errors.New("接口路径为空")
+	}
+	return parseModelUrl(r.path)
+}
+
+// Payload 实际发送的请求体
+func (r *RawRequest) Payload() any {
+	return r.body
+}
+
+// RawResponse 原始响应，包含 data_headers 和 data 两个键
+type RawResponse map[string]any
+
+// DataHeaders 返回响应的data_headers
+func (r RawResponse) DataHeaders() map[string]any {
+	headers, _ := r["data_headers"].(map[string]any)
+	return headers
+}
+
+// Data 返回响应的data，data不是map时返回nil
+func (r RawResponse) Data() map[string]any {
+	data, _ := r["data"].(map[string]any)
+	return data
+}
+
+func (r RawResponse) GetResultCode() int {
+	switch v := r.DataHeaders()["result_code"].(type) {
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func (r RawResponse) GetRequestId() string {
+	requestId, _ := r.DataHeaders()["request_id"].(string)
+	return requestId
+}
+
+func (r RawResponse) GetSID() string {
+	sid, _ := r.DataHeaders()["sid"].(string)
+	return sid
+}
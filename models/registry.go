@@ -0,0 +1,85 @@
+package models
+
+//go:generate go run gopcr/cmd/gopcr-gen -spec endpoints.yaml -models endpoints_gen.go -client ../core/endpoints_gen.go
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Endpoint 已注册接口的描述，供工具枚举（模拟服务器、文档等）
+type Endpoint struct {
+	Path      string       // 接口路径，不含查询参数，例如 "home/index"
+	Encrypted bool         // 请求是否加密
+	Request   reflect.Type // 请求结构体类型（非指针）
+	Response  reflect.Type // 响应data的类型
+
+	newRequest func() IRequest
+}
+
+// NewRequest 创建接口的默认请求（与 NewXxxReq 相同）
+func (e Endpoint) NewRequest() IRequest {
+	return e.newRequest()
+}
+
+var (
+	endpointsMu sync.RWMutex
+	endpoints   = map[string]Endpoint{}
+)
+
+// RegisterEndpoint 注册接口，Resp为响应data的类型。
+// newReq返回请求的指针，路径和是否加密由请求本身决定。路径重复时panic
+func RegisterEndpoint[Resp any](newReq func() IRequest) Endpoint {
+	req := newReq()
+	u, err := req.GetUrl()
+	if err != nil {
+		panic(fmt.Sprintf("注册接口失败: %v", err))
+	}
+	reqType := reflect.TypeOf(req)
+	if reqType.Kind() == reflect.Pointer {
+		reqType = reqType.Elem()
+	}
+	endpoint := Endpoint{
+		Path:       strings.TrimPrefix(u.Path, "/"),
+		Encrypted:  req.IsEncrypt(),
+		Request:    reqType,
+		Response:   reflect.TypeFor[Resp](),
+		newRequest: newReq,
+	}
+
+	endpointsMu.Lock()
+	defer endpointsMu.Unlock()
+	if _, ok := endpoints[endpoint.Path]; ok {
+		panic("接口重复注册: " + endpoint.Path)
+	}
+	endpoints[endpoint.Path] = endpoint
+	return endpoint
+}
+
+// LookupEndpoint 按路径查询接口，路径可以带查询参数
+func LookupEndpoint(path string) (Endpoint, bool) {
+	path, _, _ = strings.Cut(strings.TrimPrefix(path, "/"), "?")
+
+	endpointsMu.RLock()
+	defer endpointsMu.RUnlock()
+	endpoint, ok := endpoints[path]
+	return endpoint, ok
+}
+
+// Endpoints 返回所有已注册的接口，按路径排序
+func Endpoints() []Endpoint {
+	endpointsMu.RLock()
+	defer endpointsMu.RUnlock()
+
+	list := make([]Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		list = append(list, endpoint)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// 已知的API结果码 (DataHeaders.ResultCode)
+const (
+	ResultCodeSuccess        = 1   // 成功
+	ResultCodeSessionExpired = 3   // 会话失效，需要重新登录
+	ResultCodeMaintenance    = 101 // 服务器维护中
+	ResultCodeServerBusy     = 102 // 服务器繁忙
+	ResultCodeServerError    = 201 // 服务器内部错误
+	ResultCodeVersionUpdated = 204 // 客户端版本过旧，需要更新APP-VER
+	ResultCodeResourceUpdate = 205 // 资源版本已更新，需要更新MANIFEST-VER
+	ResultCodeOtherDevice    = 213 // 账号在其他设备登录
+	ResultCodeAccountBanned  = 214 // 账号已被停用
+)
+
+// ResultCodeInfo 结果码的说明
+type ResultCodeInfo struct {
+	Code    int
+	Zh      string // 中文说明
+	En      string // 英文说明
+	Err     error  // 对应的哨兵错误，可为nil
+	Success bool   // 是否表示成功
+}
+
+// builtinResultCodes 内置的结果码说明，其中的哨兵错误和Success不能通过 RegisterResultCode 修改
+var builtinResultCodes = map[int]ResultCodeInfo{
+	ResultCodeSuccess:        {Code: ResultCodeSuccess, Zh: "成功", En: "success", Success: true},
+	ResultCodeSessionExpired: {Code: ResultCodeSessionExpired, Zh: "会话已失效，请重新登录", En: "session expired, login required", Err: ErrSessionExpired},
+	ResultCodeMaintenance:    {Code: ResultCodeMaintenance, Zh: "服务器维护中", En: "server under maintenance", Err: ErrMaintenance},
+	ResultCodeServerBusy:     {Code: ResultCodeServerBusy, Zh: "服务器繁忙，请稍后再试", En: "server busy, try again later"},
+	ResultCodeServerError:    {Code: ResultCodeServerError, Zh: "服务器错误", En: "server error"},
+	ResultCodeVersionUpdated: {Code: ResultCodeVersionUpdated, Zh: "客户端版本过旧", En: "client version outdated", Err: ErrVersionUpdated},
+	ResultCodeResourceUpdate: {Code: ResultCodeResourceUpdate, Zh: "资源版本已更新", En: "resource version outdated"},
+	ResultCodeOtherDevice:    {Code: ResultCodeOtherDevice, Zh: "账号已在其他设备登录", En: "logged in on another device"},
+	ResultCodeAccountBanned:  {Code: ResultCodeAccountBanned, Zh: "账号已被停用", En: "account suspended"},
+}
+
+var (
+	resultCodesMu sync.RWMutex
+	resultCodes   = maps.Clone(builtinResultCodes)
+)
+
+// LookupResultCode 查询结果码的说明
+func LookupResultCode(code int) (ResultCodeInfo, bool) {
+	resultCodesMu.RLock()
+	defer resultCodesMu.RUnlock()
+
+	info, ok := resultCodes[code]
+	return info, ok
+}
+
+// RegisterResultCode 注册或覆盖结果码的说明，用于补充新发现的结果码。
+// 内置结果码只能修改说明，修改其哨兵错误或Success时返回错误
+func RegisterResultCode(info ResultCodeInfo) error {
+	if builtin, ok := builtinResultCodes[info.Code]; ok && (info.Err != builtin.Err || info.Success != builtin.Success) {
+		return fmt.Errorf("不能修改内置结果码%d的哨兵错误或Success", info.Code)
+	}
+
+	resultCodesMu.Lock()
+	defer resultCodesMu.Unlock()
+
+	resultCodes[info.Code] = info
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegisterResultCode(t *testing.T) {
+	t.Cleanup(func() {
+		resultCodesMu.Lock()
+		defer resultCodesMu.Unlock()
+		delete(resultCodes, 9999)
+		resultCodes[ResultCodeSessionExpired] = builtinResultCodes[ResultCodeSessionExpired]
+	})
+	sessionErr := &ApiError{Operation: "test", ApiCode: ResultCodeSessionExpired}
+
+	// 内置结果码的哨兵错误和Success不能被修改
+	for _, info := range []ResultCodeInfo{
+		{Code: ResultCodeSessionExpired, Zh: "覆盖"},
+		{Code: ResultCodeSessionExpired, Zh: "覆盖", Err: ErrMaintenance},
+		{Code: ResultCodeSessionExpired, Zh: "覆盖", Err: ErrSessionExpired, Success: true},
+		{Code: ResultCodeSuccess, Zh: "覆盖"},
+	} {
+		if err := RegisterResultCode(info); err == nil {
+			t.Fatalf("RegisterResultCode(%+v) succeeded", info)
+		}
+	}
+	if !errors.Is(sessionErr, ErrSessionExpired) {
+		t.Fatal("result code 3 no longer matches ErrSessionExpired")
+	}
+
+	// 可以修改内置结果码的说明
+	if err := RegisterResultCode(ResultCodeInfo{Code: ResultCodeSessionExpired, Zh: "请重新登录", Err: ErrSessionExpired}); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(sessionErr, ErrSessionExpired) || !strings.Contains(sessionErr.Error(), "请重新登录") {
+		t.Fatalf("got %v", sessionErr)
+	}
+
+	// 新的结果码
+	if err := RegisterResultCode(ResultCodeInfo{Code: 9999, Zh: "新发现的结果码", Err: ErrTutorialNotFinished}); err != nil {
+		t.Fatal(err)
+	}
+	err := &ApiError{Operation: "test", ApiCode: 9999}
+	if !errors.Is(err, ErrTutorialNotFinished) || !strings.Contains(err.Error(), "新发现的结果码") {
+		t.Fatalf("got %v", err)
+	}
+}
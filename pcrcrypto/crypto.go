@@ -0,0 +1,328 @@
+// Package pcrcrypto 实现游戏API的加密方案：
+// 请求体为 msgpack → PKCS#7填充 → AES-256-CBC（固定IV）→ 尾部附加32字节随机密钥，
+// 响应体在此基础上再进行Base64编码。viewer_id字段单独加密并Base64编码
+package pcrcrypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/ugorji/go/codec"
+	"gopcr/config"
+	"io"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// KeySize 附加在密文尾部的密钥长度
+const KeySize = 32
+
+var iv = []byte(config.PcrAesIV)
+
+// Codec PCR Msgpack。可以被多个goroutine并发使用
+type Codec struct {
+	mh codec.MsgpackHandle // MessagePack处理器
+
+	encoders sync.Pool // *codec.Encoder
+	decoders sync.Pool // *codec.Decoder
+}
+
+// bufPool 编码和Base64解码使用的缓冲区
+var bufPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxPooledBuf 超过该大小的缓冲区不放回池中，避免长期占用内存
+const maxPooledBuf = 1 << 20
+
+func getBuf() *bytes.Buffer {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuf(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuf {
+		bufPool.Put(buf)
+	}
+}
+
+// New 创建一个新的PCR加密器
+func New() *Codec {
+	c := &Codec{}
+	// 设置handle选项，使其行为与标准msgpack一致
+	c.mh.WriteExt = true
+	c.mh.RawToString = true
+	// 解码到any时使用map[string]any，便于转为JSON
+	c.mh.MapType = reflect.TypeOf(map[string]any(nil))
+
+	c.encoders.New = func() any { return codec.NewEncoder(nil, &c.mh) }
+	c.decoders.New = func() any { return codec.NewDecoderBytes(nil, &c.mh) }
+	return c
+}
+
+// CalcSID 计算请求头中的SID
+func CalcSID(sid string) string {
+	h := md5.New()
+	_, err := io.WriteString(h, sid+"c!SID!n")
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewKey 生成一个随机32字节的密钥（十六进制字符）
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if err := fillKey(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// fillKey 一次读取随机数填充密钥。256是16的倍数，取低4位仍是均匀分布
+func fillKey(key []byte) error {
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	for i, b := range key {
+		key[i] = "0123456789abcdef"[b&0x0f]
+	}
+	return nil
+}
+
+// sealedLen 明文加密后（含填充和密钥）的长度
+func sealedLen(n int) int {
+	return n + aes.BlockSize - n%aes.BlockSize + KeySize
+}
+
+// seal 在dst[:n]中的明文之后填充、原地加密并附加随机密钥，dst的长度必须为 sealedLen(n)
+func seal(dst []byte, n int) error {
+	padded := dst[:len(dst)-KeySize]
+	padding := byte(len(padded) - n)
+	for i := n; i < len(padded); i++ {
+		padded[i] = padding
+	}
+	key := dst[len(padded):]
+	if err := fillKey(key); err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(padded, padded)
+	return nil
+}
+
+// Encrypt 使用key加密明文，返回密文并在尾部附加key
+func Encrypt(plain []byte, key []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("密钥长度无效")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, sealedLen(len(plain)))
+	copy(out, plain)
+	padded := out[:len(out)-KeySize]
+	padding := byte(len(padded) - len(plain))
+	for i := len(plain); i < len(padded); i++ {
+		padded[i] = padding
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(padded, padded)
+	copy(out[len(padded):], key)
+	return out, nil
+}
+
+// Decrypt 解密尾部附加了密钥的密文并去除填充，不修改data
+func Decrypt(data []byte) ([]byte, error) {
+	return DecryptInPlace(append([]byte(nil), data...))
+}
+
+// DecryptInPlace 与 Decrypt 相同，但直接在data上解密，返回的明文与data共用内存
+func DecryptInPlace(data []byte) ([]byte, error) {
+	if len(data) < KeySize+aes.BlockSize || (len(data)-KeySize)%aes.BlockSize != 0 {
+		return nil, errors.New("密文长度无效")
+	}
+
+	// 从数据末尾提取密钥
+	encrypted, key := data[:len(data)-KeySize], data[len(data)-KeySize:]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(encrypted, encrypted)
+	return unpad(encrypted)
+}
+
+// unpad 改进版 PKCS#7 Unpadding
+func unpad(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("数据长度为0")
+	}
+
+	padding := int(data[len(data)-1])
+	if padding > aes.BlockSize || padding == 0 {
+		return nil, errors.New("无效的填充值")
+	}
+
+	// 验证所有填充字节是否一致
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("无效的填充")
+		}
+	}
+
+	return data[:len(data)-padding], nil
+}
+
+// DecodeBody 将抓包得到的请求体（原始字节）或响应体（Base64）还原为密文
+func DecodeBody(body []byte) []byte {
+	// 原始密文可能以空白字节开头，只在按Base64解码时去除空白
+	trimmed := bytes.TrimSpace(body)
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(trimmed)))
+	n, err := base64.StdEncoding.Decode(decoded, trimmed)
+	if err == nil && n >= KeySize+aes.BlockSize && (n-KeySize)%aes.BlockSize == 0 {
+		return decoded[:n]
+	}
+	return body
+}
+
+// Marshal 将对象编码为MessagePack格式
+func (c *Codec) Marshal(v any) ([]byte, error) {
+	buf := getBuf()
+	defer putBuf(buf)
+	if err := c.encode(buf, v); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+// encode 使用池中的编码器编码到w
+func (c *Codec) encode(w io.Writer, v any) error {
+	enc := c.encoders.Get().(*codec.Encoder)
+	enc.Reset(w)
+	err := enc.Encode(v)
+	enc.Reset(nil)
+	c.encoders.Put(enc)
+	return err
+}
+
+// Unmarshal 从MessagePack格式解码为对象。解码结果不引用data
+func (c *Codec) Unmarshal(data []byte, v any) error {
+	dec := c.decoders.Get().(*codec.Decoder)
+	dec.ResetBytes(data)
+	err := dec.Decode(v)
+	dec.ResetBytes(nil)
+	c.decoders.Put(dec)
+	return err
+}
+
+// EncryptData 加密对象数据（先编码为msgpack，再加密），返回请求体格式的原始字节
+func (c *Codec) EncryptData(v any) ([]byte, error) {
+	buf := getBuf()
+	defer putBuf(buf)
+	if err := c.encode(buf, v); err != nil {
+		return nil, err
+	}
+	// 直接在输出中填充和加密，只分配一次
+	out := make([]byte, sealedLen(buf.Len()))
+	copy(out, buf.Bytes())
+	if err := seal(out, buf.Len()); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EncryptResponse 加密对象数据并Base64编码，返回响应体格式
+func (c *Codec) EncryptResponse(v any) ([]byte, error) {
+	var out bytes.Buffer
+	if err := c.WriteResponse(&out, v); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// WriteResponse 将加密并Base64编码的响应体写入w
+func (c *Codec) WriteResponse(w io.Writer, v any) error {
+	buf := getBuf()
+	defer putBuf(buf)
+	if err := c.encode(buf, v); err != nil {
+		return err
+	}
+	n := buf.Len()
+	buf.Grow(sealedLen(n) - n)
+	sealed := buf.Bytes()[:sealedLen(n)]
+	if err := seal(sealed, n); err != nil {
+		return err
+	}
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := encoder.Write(sealed); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// EncryptViewerId 加密viewer_id并Base64编码
+func (c *Codec) EncryptViewerId(id uint64) (string, error) {
+	var plain [20]byte
+	n := len(strconv.AppendUint(plain[:0], id, 10))
+	sealed := make([]byte, sealedLen(n))
+	copy(sealed, strconv.AppendUint(plain[:0], id, 10))
+	if err := seal(sealed, n); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptViewerId 解密请求中的viewer_id字段
+func (c *Codec) DecryptViewerId(encoded string) (uint64, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, err
+	}
+	plain, err := DecryptInPlace(data)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(plain), 10, 64)
+}
+
+// DecryptBody 解密请求体或响应体（自动识别Base64）并从msgpack解码。result为解码目标的指针。
+// 不修改body
+func (c *Codec) DecryptBody(body []byte, result any) error {
+	plain, err := Decrypt(DecodeBody(body))
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(plain, result)
+}
+
+// DecryptResponse 解密Base64编码的响应体并从msgpack解码。result为解码目标的指针。
+// Base64解码、解密和解码共用一块池中的缓冲区，不修改body
+func (c *Codec) DecryptResponse(body []byte, result any) error {
+	buf := getBuf()
+	defer putBuf(buf)
+
+	body = bytes.TrimSpace(body)
+	buf.Grow(base64.StdEncoding.DecodedLen(len(body)))
+	data := buf.Bytes()[:base64.StdEncoding.DecodedLen(len(body))]
+	n, err := base64.StdEncoding.Decode(data, body)
+	if err != nil {
+		return err
+	}
+	plain, err := DecryptInPlace(data[:n])
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(plain, result)
+}
@@ -0,0 +1,181 @@
+package pcrcrypto
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type testPayload struct {
+	ViewerId uint64         `codec:"viewer_id"`
+	Name     string         `codec:"name"`
+	List     []int          `codec:"list"`
+	Extra    map[string]any `codec:"extra"`
+}
+
+func newTestPayload() testPayload {
+	list := make([]int, 64)
+	for i := range list {
+		list[i] = i * 7
+	}
+	return testPayload{ViewerId: 1000000001, Name: "测试", List: list, Extra: map[string]any{"k": "v"}}
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := New()
+	in := newTestPayload()
+
+	raw, err := c.EncryptData(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromRequest testPayload
+	if err = c.DecryptBody(raw, &fromRequest); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, fromRequest) {
+		t.Fatalf("request round trip: got %+v", fromRequest)
+	}
+
+	resp, err := c.EncryptResponse(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromResponse testPayload
+	if err = c.DecryptResponse(resp, &fromResponse); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, fromResponse) {
+		t.Fatalf("response round trip: got %+v", fromResponse)
+	}
+	// DecryptBody 同样识别Base64编码的响应
+	var auto testPayload
+	if err = c.DecryptBody(resp, &auto); err != nil || !reflect.DeepEqual(in, auto) {
+		t.Fatalf("DecryptBody on response: %v %+v", err, auto)
+	}
+
+	encoded, err := c.EncryptViewerId(in.ViewerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := c.DecryptViewerId(encoded); err != nil || id != in.ViewerId {
+		t.Fatalf("viewer id round trip: %d %v", id, err)
+	}
+}
+
+// TestPooledCodecConcurrent 池中的编码器和解码器被并发复用时结果互不影响
+func TestPooledCodecConcurrent(t *testing.T) {
+	c := New()
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			in := newTestPayload()
+			in.ViewerId = uint64(i)
+			in.List = in.List[:i]
+			for range 50 {
+				resp, err := c.EncryptResponse(in)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				var out testPayload
+				if err = c.DecryptResponse(resp, &out); err != nil {
+					t.Error(err)
+					return
+				}
+				if out.ViewerId != in.ViewerId || len(out.List) != len(in.List) {
+					t.Errorf("got viewer %d with %d items, want %d with %d", out.ViewerId, len(out.List), in.ViewerId, len(in.List))
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDecryptInPlace(t *testing.T) {
+	plain := []byte("0123456789abcdef0123") // 跨越块边界
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := Encrypt(plain, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kept := bytes.Clone(sealed)
+	got, err := Decrypt(sealed)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("Decrypt: %q %v", got, err)
+	}
+	if !bytes.Equal(sealed, kept) {
+		t.Fatal("Decrypt modified its input")
+	}
+
+	got, err = DecryptInPlace(sealed)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("DecryptInPlace: %q %v", got, err)
+	}
+	if &got[0] != &sealed[0] {
+		t.Fatal("DecryptInPlace did not reuse the input buffer")
+	}
+
+	if _, err = DecryptInPlace(make([]byte, KeySize+1)); err == nil {
+		t.Fatal("expected error for invalid length")
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != KeySize {
+		t.Fatalf("key length %d", len(key))
+	}
+	for _, b := range key {
+		if !bytes.ContainsRune([]byte("0123456789abcdef"), rune(b)) {
+			t.Fatalf("non-hex key byte %q", b)
+		}
+	}
+}
+
+func BenchmarkEncryptData(b *testing.B) {
+	c := New()
+	in := newTestPayload()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := c.EncryptData(in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecryptResponse(b *testing.B) {
+	c := New()
+	resp, err := c.EncryptResponse(newTestPayload())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		var out testPayload
+		if err = c.DecryptResponse(resp, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncryptViewerId(b *testing.B) {
+	c := New()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := c.EncryptViewerId(1000000001); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package pcrcrypto
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// UnmarshalJSON 解析JSON，整数解析为int64而不是float64，
+// 使重新编码为msgpack后的类型与游戏服务器一致
+func UnmarshalJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return NormalizeNumbers(v), nil
+}
+
+// NormalizeNumbers 将 json.Number 转为int64或float64
+func NormalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, item := range v {
+			v[k] = NormalizeNumbers(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = NormalizeNumbers(item)
+		}
+		return v
+	}
+	return v
+}